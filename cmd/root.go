@@ -10,18 +10,30 @@ import (
 )
 
 const (
-	typeModel    = "type model"
-	typeDocument = "type document"
-	typePayload  = "type payload"
+	typeModel       = "type model"
+	typeDocument    = "type document"
+	typePayload     = "type payload"
+	typeProto       = "type proto"
+	typeRow         = "type row"
+	typeRepository  = "type repository"
+	typeSchema      = "type schema"
+	gen             = "gen"
+	exportTemplates = "export-templates"
 )
 
 func Run() {
 	c := cli.NewCLI("makes-code", "0.0.0")
 	c.Args = os.Args[1:]
 	c.Commands = map[string]cli.CommandFactory{
-		typeModel:    command.TypeModel,
-		typeDocument: command.TypeDocument,
-		typePayload:  command.TypePayload,
+		typeModel:       command.TypeModel,
+		typeDocument:    command.TypeDocument,
+		typePayload:     command.TypePayload,
+		typeProto:       command.TypeProto,
+		typeRow:         command.TypeRow,
+		typeRepository:  command.TypeRepository,
+		typeSchema:      command.TypeSchema,
+		gen:             command.Gen,
+		exportTemplates: command.ExportTemplates,
 	}
 	c.HelpWriter = os.Stdout
 	c.ErrorWriter = os.Stderr
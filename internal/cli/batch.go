@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/makes-code/gen/internal/config"
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+const defaultPattern = "./..."
+
+type CmdBatch struct {
+	CmdMeta
+	Generators map[string]func() *CmdCodegen
+}
+
+func (cmd *CmdBatch) Help() string     { return cmd.CmdMeta.Help }
+func (cmd *CmdBatch) Synopsis() string { return cmd.CmdMeta.Synopsis }
+
+func (cmd *CmdBatch) Run(args []string) int {
+	var repo, configPath string
+	var mode outputMode
+	var templates templateSource
+
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.StringVar(&repo, "repo", "", "")
+	fs.StringVar(&configPath, "config", "", "")
+	mode.flags(fs)
+	templates.flags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	// a single template would replace the code of every generator in the
+	// batch, the directory holds one per generator instead
+	if templates.path != "" {
+		log.Printf("-template overrides a single generator, use -templates with a directory of <generator>%s files", templateExt)
+		return 1
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{defaultPattern}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	pkgs, pkgsErr := inspect.LoadPackages(wd, patterns...)
+	if pkgsErr != nil {
+		log.Print(pkgsErr)
+		return 1
+	}
+
+	cfg, cfgErr := config.Load(wd, configPath)
+	if cfgErr != nil {
+		log.Print(cfgErr)
+		return 1
+	}
+
+	var stale int
+	report := func(prefix string, err error) bool {
+		if _, ok := err.(staleError); ok {
+			log.Print(err)
+			stale++
+			return false
+		}
+		log.Printf("%s: %s", prefix, err)
+		return true
+	}
+
+	common := mode.args()
+	if repo != "" {
+		common = append(common, "-repo", repo)
+	}
+	if templates.dir != "" {
+		common = append(common, "-templates", templates.dir)
+	}
+
+	// a marker and the config may declare the same output file, the
+	// options configured overriding the marker ones
+	var jobs []batchJob
+	declared := map[string]int{}
+	add := func(job batchJob) error {
+		path, pathErr := cmd.path(job)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		i, ok := declared[path]
+		if !ok {
+			declared[path] = len(jobs)
+			jobs = append(jobs, job)
+			return nil
+		}
+
+		if jobs[i].configured || !job.configured {
+			return fmt.Errorf("%s output %s is already declared by %s",
+				job.kind, filepath.Base(path), jobs[i].position)
+		}
+		jobs[i].output = job.output
+		jobs[i].configured = true
+		return nil
+	}
+
+	for _, pkg := range pkgs {
+		for _, marker := range inspect.PackageMarkers(pkg) {
+			tag, _ := marker.Arg("tag")
+			job := batchJob{
+				position: marker.Decl.Position(),
+				kind:     marker.Kind,
+				tag:      tag,
+				decl:     marker.Decl,
+				args:     marker.Args,
+			}
+			if err := add(job); err != nil {
+				log.Printf("%s: %s", job.position, err)
+				return 1
+			}
+		}
+	}
+
+	var types map[string]config.Type
+	if cfg != nil {
+		types = cfg.Types
+	}
+
+	for _, typeName := range sortedKeys(types) {
+		decl, declErr := inspect.FindType(pkgs, typeName)
+		if declErr != nil {
+			log.Printf("%s: %s", cfg.Path, declErr)
+			return 1
+		}
+
+		for _, kind := range sortedKeys(types[typeName]) {
+			for _, output := range types[typeName][kind] {
+				tag, _ := output.Get("tag")
+				job := batchJob{
+					position:   cfg.Path + ": " + typeName,
+					kind:       kind,
+					tag:        tag,
+					decl:       decl,
+					output:     output,
+					configured: true,
+				}
+				if err := add(job); err != nil {
+					log.Printf("%s: %s", job.position, err)
+					return 1
+				}
+			}
+		}
+	}
+
+	// the outputs are planned upfront so that the models nested in others
+	// are encoded alike whichever is generated first
+	plan := inspect.NewPlan()
+	for _, job := range jobs {
+		plan.Add(job.decl.Package.Path, job.decl.Spec.Name.Name, job.kind, job.tag, inspect.OutputArgs{
+			Marker:     job.args,
+			Configured: job.output.Args(nil),
+		})
+	}
+
+	for _, job := range jobs {
+		err := cmd.generate(cfg, plan, common, job)
+		if err != nil && report(job.position, err) {
+			return 1
+		}
+	}
+
+	if stale > 0 {
+		return 1
+	}
+	return 0
+}
+
+// batchJob is an output declared by a marker, configured for a type, or
+// both, in which case it holds the marker args and the configured output
+type batchJob struct {
+	position   string
+	kind       string
+	tag        string
+	decl       *inspect.TypeDecl
+	args       []string
+	output     config.Output
+	configured bool
+}
+
+// path returns the file the job generates, named after its marker args or
+// configured options
+func (cmd *CmdBatch) path(job batchJob) (string, error) {
+	newGenerator, ok := cmd.Generators[job.kind]
+	if !ok {
+		return "", fmt.Errorf("unknown generator %q", job.kind)
+	}
+
+	gen := newGenerator()
+	if err := gen.Parse(append(job.args[:len(job.args):len(job.args)], job.output.Args(nil)...)); err != nil {
+		return "", err
+	}
+	return gen.path(job.decl), nil
+}
+
+func (cmd *CmdBatch) generate(cfg *config.Config, plan *inspect.Plan, common []string, job batchJob) error {
+	newGenerator, ok := cmd.Generators[job.kind]
+	if !ok {
+		return fmt.Errorf("unknown generator %q", job.kind)
+	}
+
+	gen := newGenerator()
+	gen.plan = plan
+
+	args := append(common[:len(common):len(common)], gen.unconfigured(job.args, job.output.Args(nil))...)
+	if err := gen.Parse(args); err != nil {
+		return err
+	}
+
+	if cfg != nil {
+		if err := gen.Configure(cfg, job.output); err != nil {
+			return err
+		}
+	}
+
+	return gen.Generate(job.decl)
+}
+
+// unconfigured returns the marker args of the flags the configured args
+// leave unset, whichever of their names either uses
+func (cmd *CmdCodegen) unconfigured(args, configured []string) []string {
+	fs := cmd.flagSet()
+
+	set := map[flag.Value]struct{}{}
+	for _, arg := range configured {
+		if f := fs.Lookup(flagName(arg)); f != nil {
+			set[f.Value] = struct{}{}
+		}
+	}
+
+	var out []string
+	for _, arg := range args {
+		if f := fs.Lookup(flagName(arg)); f != nil {
+			if _, ok := set[f.Value]; ok {
+				continue
+			}
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// flagName returns the name of the flag set by an arg, e.g. include for
+// -include=ID
+func flagName(arg string) string {
+	name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return name
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,20 +3,19 @@ package cli
 import (
 	"bytes"
 	"flag"
+	"fmt"
+	"go/ast"
 	"go/format"
-	"html/template"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"text/template"
 
+	"github.com/makes-code/gen/internal/config"
 	"github.com/makes-code/gen/internal/inspect"
 )
 
-const (
-	quoteEscaped = "&#34;"
-	quoteRaw     = `"`
-)
-
 type CmdMeta struct {
 	Name     string
 	Help     string
@@ -28,6 +27,44 @@ type CmdCodegen struct {
 	Flags    func(fs *flag.FlagSet)
 	Runner   func(data inspect.Data) (string, interface{}, error)
 	FileName func(systemName string) string
+	// Files returns the extra files written along with the code, once the
+	// Runner is done
+	Files func() []File
+
+	inputs    codegenInputs
+	mode      outputMode
+	templates templateSource
+	flags     *flag.FlagSet
+	// plan holds the outputs of the run, set by the batch or else from
+	// the markers of the loaded packages and the config
+	plan *inspect.Plan
+}
+
+// File is an extra file written along with the generated code, rendered
+// from the Runner template data or holding the given content. Its path is
+// relative to the package directory unless absolute.
+type File struct {
+	Name     string
+	Path     string
+	Template string
+	Content  []byte
+}
+
+type codegenInputs struct {
+	name    string
+	source  string
+	repo    string
+	pkgPath string
+	config  string
+}
+
+// target returns the name of the declared type the code is generated from,
+// the source struct of the model when set or else the model itself
+func (i codegenInputs) target() string {
+	if i.source != "" {
+		return i.source
+	}
+	return i.name
 }
 
 func NewCmdCodegen() *CmdCodegen {
@@ -39,17 +76,7 @@ func (cmd *CmdCodegen) Help() string     { return cmd.CmdMeta.Help }
 func (cmd *CmdCodegen) Synopsis() string { return cmd.CmdMeta.Synopsis }
 
 func (cmd *CmdCodegen) Run(args []string) int {
-	var name, repo string
-
-	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
-	fs.StringVar(&name, "name", "", "")
-	fs.StringVar(&repo, "repo", "", "")
-
-	if cmd.Flags != nil {
-		cmd.Flags(fs)
-	}
-
-	if err := fs.Parse(args); err != nil {
+	if err := cmd.Parse(args); err != nil {
 		log.Println(err)
 		return 1
 	}
@@ -60,83 +87,282 @@ func (cmd *CmdCodegen) Run(args []string) int {
 		return 1
 	}
 
-	names := inspect.NewNames(name, inspect.NamesOptions{})
+	cfg, cfgErr := config.Load(wd, cmd.inputs.config)
+	if cfgErr != nil {
+		log.Print(cfgErr)
+		return 1
+	}
+
+	if cfg != nil {
+		output, outputErr := cmd.configOutput(cfg)
+		if outputErr != nil {
+			log.Print(outputErr)
+			return 1
+		}
+
+		if err := cmd.Configure(cfg, output); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
+
+	patterns := []string{"."}
+	if cmd.inputs.pkgPath != "" {
+		patterns = append(patterns, cmd.inputs.pkgPath)
+	}
 
-	pkgName, files, filesErr := inspect.DirectoryGoFiles(wd)
-	if filesErr != nil {
-		log.Print(filesErr)
+	pkgs, pkgsErr := inspect.LoadPackages(wd, patterns...)
+	if pkgsErr != nil {
+		log.Print(pkgsErr)
 		return 1
 	}
 
-	parsed, parsedErr := files.FindAndParse(func(f string) bool {
-		return strings.HasSuffix(f, names.System+".go")
-	})
-	if parsedErr != nil {
-		log.Print(parsedErr)
+	cmd.plan = runPlan(pkgs, cfg)
+
+	decl, declErr := inspect.FindType(pkgs, cmd.inputs.target())
+	if declErr != nil {
+		log.Print(declErr)
 		return 1
 	}
 
-	fieldsOpts := inspect.TypeFieldsOptions{Pkg: pkgName, Target: name}
-	fields, fieldsErr := inspect.TypeFields(parsed, fieldsOpts)
-	if fieldsErr != nil {
-		log.Print(fieldsErr)
+	if err := cmd.Generate(decl); err != nil {
+		log.Print(err)
 		return 1
 	}
 
-	imports, importsErr := inspect.FileImports(repo, parsed)
+	return 0
+}
+
+// Parse reads the common and generator specific flags from the args
+func (cmd *CmdCodegen) Parse(args []string) error {
+	return cmd.flagSet().Parse(args)
+}
+
+// Configure sets the flags left unset by Parse from the config repo and the
+// output options
+func (cmd *CmdCodegen) Configure(cfg *config.Config, output config.Output) error {
+	fs := cmd.flagSet()
+
+	set := map[flag.Value]struct{}{}
+	fs.Visit(func(f *flag.Flag) { set[f.Value] = struct{}{} })
+
+	if cfg.Templates != "" {
+		output = append(config.Output{{Name: "templates", Value: cfg.Templates}}, output...)
+	}
+
+	if cfg.Repo != "" {
+		output = append(config.Output{{Name: "repo", Value: cfg.Repo}}, output...)
+	}
+
+	args := output.Args(func(name string) bool {
+		f := fs.Lookup(name)
+		if f == nil {
+			return false
+		}
+		_, ok := set[f.Value]
+		return ok
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %s", cfg.Path, err)
+	}
+	return nil
+}
+
+func (cmd *CmdCodegen) configOutput(cfg *config.Config) (config.Output, error) {
+	var tag string
+	if f := cmd.flagSet().Lookup("tag"); f != nil {
+		tag = f.Value.String()
+	}
+
+	outputs := cfg.Outputs(cmd.inputs.target(), cmd.Name, tag)
+	switch len(outputs) {
+	case 0:
+		return nil, nil
+	case 1:
+		return outputs[0], nil
+	}
+
+	tags := make([]string, len(outputs))
+	for i, o := range outputs {
+		tags[i], _ = o.Get("tag")
+	}
+	return nil, fmt.Errorf("%s: %d %s outputs configured for %s, choose one with -tag: %s",
+		cfg.Path, len(outputs), cmd.Name, cmd.inputs.target(), strings.Join(tags, ", "))
+}
+
+// runPlan plans the outputs marked in the loaded packages and configured
+// for each type, matching the configured type name in any package since
+// only the package of the target is loaded
+func runPlan(pkgs []*inspect.Package, cfg *config.Config) *inspect.Plan {
+	plan := inspect.NewPlan()
+	for _, pkg := range pkgs {
+		for _, marker := range inspect.PackageMarkers(pkg) {
+			tag, _ := marker.Arg("tag")
+			plan.Add(pkg.Path, marker.Decl.Spec.Name.Name, marker.Kind, tag, inspect.OutputArgs{Marker: marker.Args})
+		}
+	}
+
+	if cfg == nil {
+		return plan
+	}
+
+	for typeName, t := range cfg.Types {
+		for kind, outputs := range t {
+			for _, output := range outputs {
+				tag, _ := output.Get("tag")
+				plan.Add("", typeName, kind, tag, inspect.OutputArgs{Configured: output.Args(nil)})
+			}
+		}
+	}
+	return plan
+}
+
+// ParseOutput reads the flags of a planned output, its configured options
+// overriding the marker args of the same flags
+func (cmd *CmdCodegen) ParseOutput(args inspect.OutputArgs) error {
+	return cmd.Parse(append(cmd.unconfigured(args.Marker, args.Configured), args.Configured...))
+}
+
+func (cmd *CmdCodegen) flagSet() *flag.FlagSet {
+	if cmd.flags != nil {
+		return cmd.flags
+	}
+
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.StringVar(&cmd.inputs.name, "name", "", "")
+	fs.StringVar(&cmd.inputs.source, "source", "", "")
+	fs.StringVar(&cmd.inputs.repo, "repo", "", "")
+	fs.StringVar(&cmd.inputs.pkgPath, "pkg", "", "")
+	fs.StringVar(&cmd.inputs.config, "config", "", "")
+	cmd.mode.flags(fs)
+	cmd.templates.flags(fs)
+
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+
+	cmd.flags = fs
+	return fs
+}
+
+// Generate renders the code for the declared type and writes it next to the
+// file declaring it. The model is named after the type unless -name is set,
+// in which case a struct of another name is the source of its fields.
+func (cmd *CmdCodegen) Generate(decl *inspect.TypeDecl) error {
+	names := cmd.modelNames(decl)
+
+	var source string
+	if _, ok := decl.Spec.Type.(*ast.StructType); ok && decl.Spec.Name.Name != names.Public {
+		source = decl.Spec.Name.Name
+	}
+
+	fields, fieldsErr := inspect.TypeFields(decl)
+	if fieldsErr != nil {
+		return fieldsErr
+	}
+
+	imports, importsErr := inspect.FileImports(cmd.inputs.repo, decl)
 	if importsErr != nil {
-		log.Print(importsErr)
-		return 1
+		return importsErr
 	}
 
 	for _, field := range fields {
 		imports.Include(field.Type.Imports()...)
 	}
 
+	params := inspect.DeclTypeParams(decl)
+	imports.Include(params.Imports()...)
+
 	tmpl, tmplData, tmplErr := cmd.Runner(inspect.Data{
-		Pkg:     pkgName,
-		Names:   names,
-		Fields:  fields,
-		Imports: imports,
+		Pkg:        decl.Package.Name,
+		Dir:        decl.Package.Dir,
+		Names:      names,
+		Source:     source,
+		TypeParams: params,
+		Fields:     fields,
+		Imports:    imports,
+		Plan:       cmd.plan,
+		PkgPath:    decl.Package.Path,
 	})
 	if tmplErr != nil {
-		log.Print(tmplErr)
-		return 1
+		return tmplErr
+	}
+
+	tmpl, tmplErr = cmd.templates.resolve(cmd.Name, tmpl)
+	if tmplErr != nil {
+		return tmplErr
 	}
 
-	src, srcErr := generateCode(cmd.Name, tmpl, tmplData)
+	path := cmd.path(decl)
+
+	src, srcErr := generateCode(cmd.Name, path, tmpl, tmplData)
 	if srcErr != nil {
-		log.Print(srcErr)
-		return 1
+		return srcErr
 	}
 
-	if err := writeFile(cmd.FileName(names.System), src); err != nil {
-		log.Print(err)
-		return 1
+	if err := cmd.mode.emit(path, src); err != nil {
+		return err
 	}
 
-	return 0
+	if cmd.Files == nil {
+		return nil
+	}
+
+	for _, file := range cmd.Files() {
+		path := file.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(decl.Package.Dir, path)
+		}
+
+		src := file.Content
+		if file.Template != "" {
+			tmpl, tmplErr := cmd.templates.lookup(file.Name, file.Template)
+			if tmplErr != nil {
+				return tmplErr
+			}
+
+			var srcErr error
+			if src, srcErr = generateCode(file.Name, path, tmpl, tmplData); srcErr != nil {
+				return srcErr
+			}
+		}
+
+		if err := cmd.mode.emit(path, src); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func generateCode(name, tmpl string, tmplData interface{}) ([]byte, error) {
-	src := new(bytes.Buffer)
-	if err := template.Must(
-		template.New(name).Parse(tmpl),
-	).Execute(src, tmplData); err != nil {
-		return nil, err
+// modelNames names the model after the declared type unless -name is set
+func (cmd *CmdCodegen) modelNames(decl *inspect.TypeDecl) inspect.Names {
+	name := cmd.inputs.name
+	if name == "" {
+		name = decl.Spec.Name.Name
 	}
+	return inspect.NewNames(name, inspect.NamesOptions{})
+}
 
-	return format.Source([]byte(
-		strings.ReplaceAll(src.String(), quoteEscaped, quoteRaw),
-	))
+// path returns the path of the file generated for the declared type
+func (cmd *CmdCodegen) path(decl *inspect.TypeDecl) string {
+	return filepath.Join(decl.Package.Dir, cmd.FileName(cmd.modelNames(decl).System))
 }
 
-func writeFile(path string, data []byte) error {
-	file, createErr := os.Create(path)
-	if createErr != nil {
-		return createErr
+// generateCode renders the template, formatting the output of Go files
+func generateCode(name, path, tmpl string, tmplData interface{}) ([]byte, error) {
+	t, parseErr := template.New(name).Funcs(TemplateFuncs).Parse(tmpl)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	src := new(bytes.Buffer)
+	if err := t.Execute(src, tmplData); err != nil {
+		return nil, err
 	}
 
-	_, writeErr := file.Write(data)
-	return writeErr
+	if filepath.Ext(path) != ".go" {
+		return src.Bytes(), nil
+	}
+	return format.Source(src.Bytes())
 }
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/fatih/camelcase"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+// TemplateFuncs are available to the built-in and user templates:
+//
+//	camel "user_id"             userId
+//	pascal "user id"            UserId
+//	snake "UserID"              user_id
+//	kebab "UserID"              user-id
+//	pluralize "Identity"        Identities
+//	quote "name"                "name"
+//	backtick `json:"n"`         `json:"n"`
+//	join ", " .List             a, b
+//	alias .Imports "encoding/json"  json
+//
+// The casing funcs split words on camel case boundaries as well as on spaces,
+// underscores and dashes, keeping initialisms like ID together.
+var TemplateFuncs = template.FuncMap{
+	"camel":     camelCase,
+	"pascal":    pascalCase,
+	"snake":     func(s string) string { return strings.Join(lowerWords(s), "_") },
+	"kebab":     func(s string) string { return strings.Join(lowerWords(s), "-") },
+	"pluralize": Pluralize,
+	"quote":     strconv.Quote,
+	"backtick":  func(s string) string { return "`" + s + "`" },
+	"join":      func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"alias":     func(imports inspect.Imports, path string) string { return imports.Alias(path) },
+}
+
+func words(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '_' || r == '-'
+	}) {
+		out = append(out, camelcase.Split(part)...)
+	}
+	return out
+}
+
+func lowerWords(s string) []string {
+	parts := words(s)
+	for i, p := range parts {
+		parts[i] = strings.ToLower(p)
+	}
+	return parts
+}
+
+func pascalCase(s string) string {
+	parts := lowerWords(s)
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func camelCase(s string) string {
+	parts := lowerWords(s)
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// Pluralize returns the plural of an English noun, e.g. the default table
+// name of a model
+func Pluralize(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case lower == "":
+		return s
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsAny(lower[len(lower)-2:len(lower)-1], "aeiou"):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(lower, "s"),
+		strings.HasSuffix(lower, "x"),
+		strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"),
+		strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// outputMode controls what happens to the generated code. By default it
+// overwrites the existing file; the dry-run, diff and check modes leave the
+// working tree untouched.
+type outputMode struct {
+	dryRun bool
+	diff   bool
+	check  bool
+}
+
+func (m *outputMode) flags(fs *flag.FlagSet) {
+	fs.BoolVar(&m.dryRun, "dry-run", false, "")
+	fs.BoolVar(&m.diff, "diff", false, "")
+	fs.BoolVar(&m.check, "check", false, "")
+}
+
+// args returns the flags reproducing the mode, passed on to the generators
+// run in batch
+func (m outputMode) args() []string {
+	return []string{
+		fmt.Sprintf("-dry-run=%t", m.dryRun),
+		fmt.Sprintf("-diff=%t", m.diff),
+		fmt.Sprintf("-check=%t", m.check),
+	}
+}
+
+// staleError is returned in check mode when the file on disk differs from the
+// generated code
+type staleError struct {
+	path string
+}
+
+func (err staleError) Error() string {
+	return err.path + " is out of date, regenerate it"
+}
+
+func (m outputMode) emit(path string, src []byte) error {
+	if m.dryRun {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+
+	if !m.diff && !m.check {
+		return writeFile(path, src)
+	}
+
+	current, readErr := ioutil.ReadFile(path)
+	if readErr != nil && !os.IsNotExist(readErr) {
+		return readErr
+	}
+
+	if bytes.Equal(current, src) {
+		return nil
+	}
+
+	if m.diff {
+		diff, diffErr := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(current)),
+			B:        difflib.SplitLines(string(src)),
+			FromFile: path,
+			ToFile:   path,
+			Context:  3,
+		})
+		if diffErr != nil {
+			return diffErr
+		}
+
+		if _, err := io.WriteString(os.Stdout, diff); err != nil {
+			return err
+		}
+	}
+
+	if m.check {
+		return staleError{path}
+	}
+	return nil
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, createErr := os.Create(path)
+	if createErr != nil {
+		return createErr
+	}
+	defer file.Close()
+
+	_, writeErr := file.Write(data)
+	return writeErr
+}
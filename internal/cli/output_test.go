@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what the function writes to the standard output
+func captureStdout(t *testing.T, f func() error) (string, error) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		src, _ := io.ReadAll(r)
+		out <- string(src)
+	}()
+
+	fErr := f()
+	w.Close()
+	return <-out, fErr
+}
+
+func TestOutputModeEmit(t *testing.T) {
+	const current = "package app\n\nvar a = 1\n"
+	const generated = "package app\n\nvar a = 2\n"
+
+	for _, tt := range []struct {
+		name    string
+		mode    outputMode
+		missing bool
+		src     string
+		stdout  []string
+		stale   bool
+		want    string
+	}{
+		{name: "write", src: generated, want: generated},
+		{name: "write missing", missing: true, src: generated, want: generated},
+		{name: "dry-run", mode: outputMode{dryRun: true}, src: generated, stdout: []string{generated}, want: current},
+		{name: "dry-run missing", mode: outputMode{dryRun: true}, missing: true, src: generated, stdout: []string{generated}},
+		{name: "dry-run check", mode: outputMode{dryRun: true, check: true}, src: generated, stdout: []string{generated}, want: current},
+		{name: "diff", mode: outputMode{diff: true}, src: generated, stdout: []string{"-var a = 1\n", "+var a = 2\n"}, want: current},
+		{name: "diff missing", mode: outputMode{diff: true}, missing: true, src: generated, stdout: []string{"+package app\n", "+var a = 2\n"}},
+		{name: "diff up to date", mode: outputMode{diff: true}, src: current, want: current},
+		{name: "check", mode: outputMode{check: true}, src: generated, stale: true, want: current},
+		{name: "check missing", mode: outputMode{check: true}, missing: true, src: generated, stale: true},
+		{name: "check up to date", mode: outputMode{check: true}, src: current, want: current},
+		{name: "diff check", mode: outputMode{diff: true, check: true}, src: generated, stdout: []string{"+var a = 2\n"}, stale: true, want: current},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "user_gen.go")
+			if !tt.missing {
+				if err := os.WriteFile(path, []byte(current), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stdout, err := captureStdout(t, func() error { return tt.mode.emit(path, []byte(tt.src)) })
+
+			if _, stale := err.(staleError); stale != tt.stale || (err != nil && !stale) {
+				t.Errorf("emit returned %v, want a stale error %t", err, tt.stale)
+			}
+
+			if len(tt.stdout) == 0 && stdout != "" {
+				t.Errorf("emit printed:\n%s", stdout)
+			}
+			for _, s := range tt.stdout {
+				if !strings.Contains(stdout, s) {
+					t.Errorf("emit printed:\n%s\nwant it to contain %q", stdout, s)
+				}
+			}
+
+			src, readErr := os.ReadFile(path)
+			switch {
+			case tt.want == "" && !os.IsNotExist(readErr):
+				t.Errorf("emit created %s", path)
+			case tt.want != "" && readErr != nil:
+				t.Fatal(readErr)
+			case string(src) != tt.want:
+				t.Errorf("%s holds:\n%s\nwant:\n%s", path, src, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const templateExt = ".tmpl"
+
+// templateSource locates the user templates overriding the built-in ones,
+// either a single file or a directory holding <generator>.tmpl files
+type templateSource struct {
+	path string
+	dir  string
+}
+
+func (ts *templateSource) flags(fs *flag.FlagSet) {
+	fs.StringVar(&ts.path, "template", "", "")
+	fs.StringVar(&ts.dir, "templates", "", "")
+}
+
+// resolve returns the template used by the named generator, falling back to
+// its built-in template
+func (ts templateSource) resolve(name, builtin string) (string, error) {
+	if ts.path != "" {
+		src, err := ioutil.ReadFile(ts.path)
+		return string(src), err
+	}
+	return ts.lookup(name, builtin)
+}
+
+// lookup returns the named template of the templates directory, falling back
+// to the built-in template. Unlike resolve it ignores the single template,
+// which only overrides the generated code.
+func (ts templateSource) lookup(name, builtin string) (string, error) {
+	if ts.dir == "" {
+		return builtin, nil
+	}
+
+	src, err := ioutil.ReadFile(filepath.Join(ts.dir, name+templateExt))
+	if os.IsNotExist(err) {
+		return builtin, nil
+	}
+	return string(src), err
+}
+
+type CmdExportTemplates struct {
+	CmdMeta
+	Templates map[string]string
+}
+
+func (cmd *CmdExportTemplates) Help() string     { return cmd.CmdMeta.Help }
+func (cmd *CmdExportTemplates) Synopsis() string { return cmd.CmdMeta.Synopsis }
+
+func (cmd *CmdExportTemplates) Run(args []string) int {
+	var dir string
+	var force bool
+
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.StringVar(&dir, "dir", ".", "")
+	fs.BoolVar(&force, "force", false, "")
+
+	if err := fs.Parse(args); err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	for _, name := range sortedKeys(cmd.Templates) {
+		path := filepath.Join(dir, name+templateExt)
+
+		if _, err := os.Stat(path); err == nil && !force {
+			log.Printf("%s already exists, use -force to overwrite it", path)
+			return 1
+		}
+
+		if err := writeFile(path, []byte(cmd.Templates[name])); err != nil {
+			log.Print(err)
+			return 1
+		}
+		fmt.Println(path)
+	}
+
+	return 0
+}
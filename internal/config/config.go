@@ -0,0 +1,292 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the config file names looked up by Find, in order
+var FileNames = []string{"makes-code.yaml", "makes-code.yml", "makes-code.toml"}
+
+// Config declares the outputs generated for each type of a project, e.g.
+//
+//	repo: github.com/makes-code/gen
+//	types:
+//	  User:
+//	    model: {}
+//	    payload:
+//	      - tag: Partial
+//	        strict: true
+//	        include: [ID, Name=n]
+//
+// Output options are named after the generator flags they set. The templates
+// directory and template paths are relative to the config file.
+type Config struct {
+	Path      string
+	Repo      string
+	Templates string
+	Types     map[string]Type
+}
+
+// Type maps generator names to the outputs configured for a type
+type Type map[string][]Output
+
+// Output holds the options of a single generated file
+type Output []Option
+
+type Option struct {
+	Name  string
+	Value string
+}
+
+// Find looks for a config file in the directory and its parents, returning
+// an empty path if there is none
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			} else if !os.IsNotExist(err) {
+				return "", err
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the config at the path, or the one found from dir when the
+// path is empty. A nil config is returned if there is none.
+func Load(dir, path string) (*Config, error) {
+	if path == "" {
+		found, err := Find(dir)
+		if err != nil || found == "" {
+			return nil, err
+		}
+		path = found
+	}
+
+	src, srcErr := ioutil.ReadFile(path)
+	if srcErr != nil {
+		return nil, srcErr
+	}
+
+	raw := map[string]interface{}{}
+
+	var err error
+	switch filepath.Ext(path) {
+	case ".toml":
+		err = toml.Unmarshal(src, &raw)
+	default:
+		err = yaml.Unmarshal(src, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	cfg, cfgErr := parseConfig(raw)
+	if cfgErr != nil {
+		return nil, fmt.Errorf("%s: %s", path, cfgErr)
+	}
+
+	cfg.Path = path
+	cfg.resolvePaths(filepath.Dir(path))
+	return cfg, nil
+}
+
+// Outputs returns the outputs of a generator configured for a type,
+// restricted to the given tag when it is set
+func (cfg *Config) Outputs(typeName, generator, tag string) []Output {
+	if cfg == nil {
+		return nil
+	}
+
+	var out []Output
+	for _, o := range cfg.Types[typeName][generator] {
+		if outputTag, _ := o.Get("tag"); tag == "" || outputTag == tag {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Get returns the value of the last option with the given name
+func (o Output) Get(name string) (string, bool) {
+	for i := len(o) - 1; i >= 0; i-- {
+		if o[i].Name == name {
+			return o[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// Args formats the options as flags, leaving out the options skipped
+func (o Output) Args(skip func(name string) bool) []string {
+	var args []string
+	for _, opt := range o {
+		if skip != nil && skip(opt.Name) {
+			continue
+		}
+		args = append(args, fmt.Sprintf("-%s=%s", opt.Name, opt.Value))
+	}
+	return args
+}
+
+func (cfg *Config) resolvePaths(dir string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	cfg.Templates = resolve(cfg.Templates)
+	for _, t := range cfg.Types {
+		for _, outputs := range t {
+			for _, output := range outputs {
+				for i, opt := range output {
+					if opt.Name == "template" {
+						output[i].Value = resolve(opt.Value)
+					}
+				}
+			}
+		}
+	}
+}
+
+func parseConfig(raw map[string]interface{}) (*Config, error) {
+	cfg := Config{Types: map[string]Type{}}
+
+	for key, value := range raw {
+		switch key {
+		case "repo":
+			repo, ok := value.(string)
+			if !ok {
+				return nil, errors.New("repo must be a string")
+			}
+			cfg.Repo = repo
+		case "templates":
+			templates, ok := value.(string)
+			if !ok {
+				return nil, errors.New("templates must be a string")
+			}
+			cfg.Templates = templates
+		case "types":
+			types, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("types must be a table of type names")
+			}
+			for typeName, typeValue := range types {
+				t, err := parseType(typeValue)
+				if err != nil {
+					return nil, fmt.Errorf("types.%s: %s", typeName, err)
+				}
+				cfg.Types[typeName] = t
+			}
+		default:
+			return nil, fmt.Errorf("unknown key %q", key)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func parseType(raw interface{}) (Type, error) {
+	generators, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("must be a table of generator names")
+	}
+
+	t := Type{}
+	for generator, value := range generators {
+		var outputs []interface{}
+		switch v := value.(type) {
+		case nil:
+			outputs = []interface{}{map[string]interface{}{}}
+		case []interface{}:
+			outputs = v
+		case []map[string]interface{}:
+			for _, o := range v {
+				outputs = append(outputs, o)
+			}
+		default:
+			outputs = []interface{}{v}
+		}
+
+		for i, o := range outputs {
+			output, err := parseOutput(o)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %s", generator, i, err)
+			}
+			t[generator] = append(t[generator], output)
+		}
+	}
+	return t, nil
+}
+
+func parseOutput(raw interface{}) (Output, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	opts, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("must be a table of options")
+	}
+
+	keys := make([]string, 0, len(opts))
+	for key := range opts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out Output
+	for _, key := range keys {
+		values, err := optionValues(opts[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err)
+		}
+		for _, v := range values {
+			out = append(out, Option{Name: key, Value: v})
+		}
+	}
+	return out, nil
+}
+
+func optionValues(raw interface{}) ([]string, error) {
+	switch v := raw.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			itemValues, err := optionValues(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	case map[string]interface{}:
+		return nil, errors.New("nested tables are not supported")
+	case nil:
+		return nil, nil
+	}
+	return []string{strings.TrimSpace(fmt.Sprint(raw))}, nil
+}
@@ -2,63 +2,252 @@ package inspect
 
 import (
 	"errors"
+	"fmt"
 	"go/ast"
-	"go/build"
-	"go/parser"
 	"go/token"
-	"io/ioutil"
-	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
 )
 
+// loadMode type-checks the packages from source, their dependencies being
+// read from export data
+const loadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedTypes |
+	packages.NeedSyntax |
+	packages.NeedTypesInfo
+
+// declMode only parses the packages declaring the embedded and nested types
+// of the loaded ones, their types being known from export data already
+const declMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedCompiledGoFiles |
+	packages.NeedSyntax
+
 type ParsedFile struct {
 	*ast.File
 	tokens *token.FileSet
+	pkg    *packages.Package
+	// dir is the directory the package was loaded from, which the packages
+	// it imports are loaded from as well
+	dir string
+}
+
+// Name returns the path of the parsed file
+func (pf *ParsedFile) Name() string {
+	return pf.tokens.File(pf.Pos()).Name()
+}
+
+type Files []*ParsedFile
+
+type Package struct {
+	Name  string
+	Path  string
+	Dir   string
+	Files Files
 }
 
-type Files []string
+// LoadPackage parses and type-checks the package in the given directory.
+// Type errors are tolerated since the package may reference code that has
+// yet to be generated.
+func LoadPackage(path string) (*Package, error) {
+	pkgs, err := LoadPackages(path, rootDir)
+	if err != nil {
+		return nil, err
+	}
 
-func (fs Files) FindAndParse(fn func(string) bool) (*ParsedFile, error) {
-	pf := ParsedFile{tokens: token.NewFileSet()}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", path, len(pkgs))
+	}
+	return pkgs[0], nil
+}
+
+// LoadPackages parses and type-checks the packages matching the patterns,
+// which are resolved relative to the given directory
+func LoadPackages(path string, patterns ...string) ([]*Package, error) {
+	cfg := packages.Config{Mode: loadMode, Dir: path}
+
+	pkgs, pkgsErr := packages.Load(&cfg, patterns...)
+	if pkgsErr != nil {
+		return nil, pkgsErr
+	}
 
-	var file string
-	for _, f := range fs {
-		if fn(f) {
-			file = f
-			break
+	seen := map[string]struct{}{}
+
+	out := make([]*Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if err := loadErrors(pkg); err != nil {
+			return nil, err
+		}
+
+		if _, ok := seen[pkg.PkgPath]; ok {
+			continue
 		}
+		seen[pkg.PkgPath] = struct{}{}
+
+		out = append(out, newPackage(pkg, path))
+	}
+	return out, nil
+}
+
+func newPackage(pkg *packages.Package, dir string) *Package {
+	files := make(Files, len(pkg.Syntax))
+	for i, f := range pkg.Syntax {
+		files[i] = &ParsedFile{File: f, tokens: pkg.Fset, pkg: pkg, dir: dir}
+	}
+
+	return &Package{
+		Name:  pkg.Name,
+		Path:  pkg.PkgPath,
+		Dir:   pkg.Dir,
+		Files: files,
+	}
+}
+
+// declPackages caches the packages parsed by importedPackage by directory
+// and import path
+var declPackages = struct {
+	sync.Mutex
+	byKey map[[2]string]*Package
+}{byKey: map[[2]string]*Package{}}
+
+// importedPackage returns the package with the import path, parsed from
+// the directory of the file unless it is the package of the file
+func importedPackage(file *ParsedFile, path string) (*Package, error) {
+	if path == file.pkg.PkgPath {
+		return newPackage(file.pkg, file.dir), nil
 	}
 
-	if file == "" {
-		return nil, errors.New("failed to find file")
+	declPackages.Lock()
+	defer declPackages.Unlock()
+
+	key := [2]string{file.dir, path}
+	if pkg, ok := declPackages.byKey[key]; ok {
+		return pkg, nil
 	}
 
-	src, srcErr := ioutil.ReadFile(file)
-	if srcErr != nil {
-		return nil, srcErr
+	cfg := packages.Config{Mode: declMode, Dir: file.dir}
+	pkgs, err := packages.Load(&cfg, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package %s, found %d", path, len(pkgs))
 	}
+	if err := loadErrors(pkgs[0]); err != nil {
+		return nil, err
+	}
+
+	pkg := newPackage(pkgs[0], file.dir)
+	declPackages.byKey[key] = pkg
+	return pkg, nil
+}
+
+// TypeDecl is a named type declared in one of the loaded packages
+type TypeDecl struct {
+	Package *Package
+	File    *ParsedFile
+	Spec    *ast.TypeSpec
+
+	q *typeQualifier
+}
 
-	parsed, parsedErr := parser.ParseFile(pf.tokens, file, src, parser.ParseComments)
-	if parsedErr != nil {
-		return nil, parsedErr
+// qualifier returns the qualifier of the types referenced by the generated
+// code, which records the packages they require but the file does not
+// import
+func (d *TypeDecl) qualifier() *typeQualifier {
+	if d.q == nil {
+		d.q = newTypeQualifier(d.File)
 	}
+	return d.q
+}
 
-	pf.File = parsed
-	return &pf, nil
+// Position returns the file and line of the declaration
+func (d *TypeDecl) Position() string {
+	pos := d.File.tokens.Position(d.Spec.Pos())
+	return fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
 }
 
-func DirectoryGoFiles(path string) (string, Files, error) {
-	pkg, pkgErr := build.ImportDir(path, 0)
-	if pkgErr != nil {
-		return "", nil, pkgErr
+// FindType searches every file of the packages for the named type,
+// reporting the candidates when the type is missing or ambiguous
+func FindType(pkgs []*Package, name string) (*TypeDecl, error) {
+	var found []*TypeDecl
+	var candidates []string
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, spec := range fileTypeSpecs(file) {
+				switch {
+				case spec.Name.Name == name:
+					found = append(found, &TypeDecl{Package: pkg, File: file, Spec: spec})
+				case isModelSpec(spec):
+					candidates = append(candidates, pkg.Name+"."+spec.Name.Name)
+				}
+			}
+		}
 	}
 
-	files := make([]string, len(pkg.GoFiles))
-	copy(files, pkg.GoFiles)
+	switch len(found) {
+	case 0:
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("type %s not found: no interface or struct types declared", name)
+		}
+		sort.Strings(candidates)
+		return nil, fmt.Errorf("type %s not found, candidates are: %s", name, strings.Join(candidates, ", "))
+	case 1:
+		return found[0], nil
+	}
 
-	if path != "." {
-		for i, f := range files {
-			files[i] = filepath.Join(path, f)
+	positions := make([]string, len(found))
+	for i, decl := range found {
+		positions[i] = decl.Position()
+	}
+	return nil, fmt.Errorf("type %s is ambiguous, declared at: %s", name, strings.Join(positions, ", "))
+}
+
+func fileTypeSpecs(file *ParsedFile) []*ast.TypeSpec {
+	var specs []*ast.TypeSpec
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
 		}
+		for _, spec := range gen.Specs {
+			if t, ok := spec.(*ast.TypeSpec); ok {
+				specs = append(specs, t)
+			}
+		}
+	}
+	return specs
+}
+
+func isModelSpec(spec *ast.TypeSpec) bool {
+	switch spec.Type.(type) {
+	case *ast.InterfaceType, *ast.StructType:
+		return true
+	}
+	return false
+}
+
+func loadErrors(pkg *packages.Package) error {
+	var msgs []string
+	for _, err := range pkg.Errors {
+		if err.Kind == packages.TypeError {
+			continue
+		}
+		// go list compiles the package to read the export data of its
+		// dependencies, reporting its type errors again as a build error
+		if strings.HasPrefix(err.Msg, "# "+pkg.PkgPath+"\n") {
+			continue
+		}
+		msgs = append(msgs, err.Error())
+	}
+
+	if len(msgs) == 0 {
+		return nil
 	}
-	return pkg.Name, files, nil
+	return errors.New(strings.Join(msgs, "\n"))
 }
@@ -0,0 +1,13 @@
+package inspect
+
+import (
+	"testing"
+)
+
+func TestLoadPackagesToleratesTypeErrors(t *testing.T) {
+	fields, err := TypeFields(loadDecl(t, "testdata/pending", "User"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFields(t, fields, "ID string", "Created time.Time", "Name string")
+}
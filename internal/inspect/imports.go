@@ -7,8 +7,14 @@ import (
 	"strings"
 )
 
-func FileImports(repo string, file *ParsedFile) (Imports, error) {
+// FileImports returns the imports of the file declaring the type, along with
+// the packages the types of its fields reference without the file importing
+// them, such as those of flattened embedded fields. It must be called once
+// the fields are collected by TypeFields.
+func FileImports(repo string, decl *TypeDecl) (Imports, error) {
 	imports := Imports{repo: repo, stmtsByAlias: map[string]string{}}
+	file := decl.File
+	q := decl.qualifier()
 
 	var err error
 	ast.Inspect(file.File, func(node ast.Node) bool {
@@ -27,18 +33,15 @@ func FileImports(repo string, file *ParsedFile) (Imports, error) {
 		}
 
 		if path := strings.Trim(i.Path.Value, `"`); path != "C" {
-			var pkg string
-			if i.Name != nil {
-				pkg = i.Name.Name
-			} else {
-				pkg = pkgName(path, rootDir)
-			}
-
-			imports.Add(pkg, out.String())
+			imports.Add(q.aliases[path], out.String())
 		}
 
 		return false
 	})
 
+	for alias, stmt := range q.imports() {
+		imports.Add(alias, stmt)
+	}
+
 	return imports, err
 }
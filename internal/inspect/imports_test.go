@@ -0,0 +1,43 @@
+package inspect
+
+import (
+	"testing"
+)
+
+func TestFileImportsAddsOnlyReferencedPackages(t *testing.T) {
+	decl := loadDecl(t, "testdata/embed", "Doc")
+
+	fields, err := TypeFields(decl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// html/template clashes with the text/template imported by the file
+	assertFields(t, fields, "Body htmltemplate.HTML", "Tmpl *template.Template")
+
+	imports, err := FileImports("", decl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"html/template": "htmltemplate",
+		"text/template": "template",
+		"fmt":           "",
+		"time":          "",
+	} {
+		if alias := imports.Alias(path); alias != want {
+			t.Errorf("alias of %s = %q, want %q", path, alias, want)
+		}
+	}
+
+	for _, f := range fields {
+		imports.Include(f.Type.Imports()...)
+	}
+
+	groups := imports.Groups()
+	if len(groups) != 1 || len(groups[0]) != 2 ||
+		groups[0][0] != `htmltemplate "html/template"` || groups[0][1] != `"text/template"` {
+		t.Errorf("imports = %q", groups)
+	}
+}
@@ -0,0 +1,95 @@
+package inspect
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+const markerPrefix = "makes-code:"
+
+// Marker is a generator request declared by a comment on a type, e.g.
+//
+//	// makes-code:payload tag=Partial strict include=ID,Name=n
+//	type User interface { ... }
+type Marker struct {
+	Kind string
+	Args []string
+	Decl *TypeDecl
+}
+
+// Arg returns the value of a marker argument, "true" for bare flags
+func (m Marker) Arg(name string) (string, bool) {
+	for _, arg := range m.Args {
+		key, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "-"), "=")
+		if key != name {
+			continue
+		}
+		if !hasValue {
+			value = "true"
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// PackageMarkers returns the markers declared on the package types in
+// declaration order
+func PackageMarkers(pkg *Package) []Marker {
+	var markers []Marker
+
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				t, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+
+				doc := t.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+
+				for _, m := range parseMarkers(doc) {
+					m.Decl = &TypeDecl{Package: pkg, File: file, Spec: t}
+					markers = append(markers, m)
+				}
+			}
+		}
+	}
+
+	return markers
+}
+
+func parseMarkers(doc *ast.CommentGroup) []Marker {
+	if doc == nil {
+		return nil
+	}
+
+	var markers []Marker
+	for _, c := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if !strings.HasPrefix(text, markerPrefix) {
+			continue
+		}
+
+		parts := strings.Fields(strings.TrimPrefix(text, markerPrefix))
+		if len(parts) == 0 {
+			continue
+		}
+
+		args := make([]string, len(parts)-1)
+		for i, p := range parts[1:] {
+			args[i] = "-" + p
+		}
+
+		markers = append(markers, Marker{Kind: parts[0], Args: args})
+	}
+	return markers
+}
@@ -2,11 +2,10 @@ package inspect
 
 import (
 	"fmt"
-	"go/build"
+	"go/token"
 	"path"
 	"strconv"
 	"strings"
-	"unicode"
 
 	"github.com/fatih/camelcase"
 )
@@ -17,147 +16,54 @@ const (
 
 type Data struct {
 	Pkg     string
+	PkgPath string
+	Dir     string
 	Names   Names
-	Fields  []Field
-	Imports Imports
+	// Source is the struct declaring the fields of a model generated from
+	// it, e.g. the userSpec of a User model, or empty when the declared type
+	// is the model itself
+	Source     string
+	TypeParams TypeParams
+	Fields     []Field
+	Imports    Imports
+	// Plan holds the outputs generated in the same run, nil when there are
+	// none besides this one
+	Plan *Plan
 }
 
 type Field struct {
 	Names Names
 	Type  FieldType
-	Tags  map[string]string
+	Tags  Tags
 }
 
-func NewField(pkg, model, name, rawType, rawTags string) Field {
+func NewField(model, name string, fieldType FieldType, rawTags string) Field {
 	return Field{
 		Names: NewNames(name, NamesOptions{Prefix: model}),
-		Type:  newFieldType(pkg, rawType),
-		Tags:  newFieldTags(rawTags),
+		Type:  fieldType,
+		Tags:  ParseTags(rawTags),
 	}
 }
 
-func (f Field) Tag(tag string) string { return fmt.Sprintf("`%s:%q`", tag, f.Names.Field) }
-
-type FieldType interface {
-	fmt.Stringer
-	Imports() []string
+// Lookup returns the value of a struct tag key, or of an annotation comment
+// of the same form on an interface method
+func (f Field) Lookup(key string) (string, bool) {
+	v, ok := f.Tags[key]
+	return v.Raw, ok
 }
 
-func newFieldType(pkg, typeRaw string) FieldType {
-	name := strings.TrimSpace(typeRaw)
-
-	if strings.HasPrefix(name, "[]") {
-		return arrayFieldType{newFieldType(pkg, name[2:])}
-	}
-
-	if strings.HasPrefix(name, "map") {
-		return mapFieldType{
-			newFieldType(pkg, name[4:strings.Index(name, "]")]),
-			newFieldType(pkg, name[strings.Index(name, "]")+1:]),
-		}
-	}
-
-	pointer := strings.HasPrefix(name, "*")
-	if pointer {
-		name = name[1:]
-	}
-
-	var typePkg, typeName string
-
-	if unicode.IsUpper(rune(name[0])) {
-		typePkg = pkg
-		typeName = name
-	} else if i := strings.Index(name, "."); i > 0 {
-		typePkg = name[0:i]
-		typeName = name[i+1:]
-	} else {
-		typeName = name
+// Tag formats the struct tag of a generated field, named after the field and
+// keeping the options of the source tag
+func (f Field) Tag(tag string) string {
+	if f.Tags[tag].Has("-") {
+		return fmt.Sprintf("`%s:\"-\"`", tag)
 	}
 
-	return scalarFieldType{pointer, typePkg, typeName}
-}
-
-type scalarFieldType struct {
-	pointer bool
-	pkg     string
-	name    string
-}
-
-func (t scalarFieldType) String() string {
-	sb := strings.Builder{}
-	if t.pointer {
-		sb.WriteString("*")
-	}
-	if t.pkg != "" {
-		sb.WriteString(t.pkg + ".")
+	value := f.Names.Field
+	if opts := f.Tags[tag].Options; len(opts) > 0 {
+		value += "," + strings.Join(opts, ",")
 	}
-	sb.WriteString(t.name)
-	return sb.String()
-}
-
-func (t scalarFieldType) Imports() []string {
-	if t.pkg == "" {
-		return nil
-	}
-	return []string{t.pkg}
-}
-
-type arrayFieldType struct {
-	elemType interface{}
-}
-
-func (t arrayFieldType) String() string {
-	return fmt.Sprintf("[]%s", typeString(t.elemType))
-}
-
-func (t arrayFieldType) Imports() []string {
-	return typeImports(t.elemType)
-}
-
-type mapFieldType struct {
-	keyType   interface{}
-	valueType interface{}
-}
-
-func (t mapFieldType) String() string {
-	return fmt.Sprintf("map[%s]%s", typeString(t.keyType), typeString(t.valueType))
-}
-
-func (t mapFieldType) Imports() []string {
-	var imports []string
-	imports = append(imports, typeImports(t.keyType)...)
-	imports = append(imports, typeImports(t.valueType)...)
-	return imports
-}
-
-func typeImports(tt interface{}) []string {
-	if t, ok := tt.(FieldType); ok {
-		return t.Imports()
-	}
-	return nil
-}
-
-func typeString(tt interface{}) string {
-	if t, ok := tt.(fmt.Stringer); ok {
-		return t.String()
-	}
-	return "interface{}"
-}
-
-func newFieldTags(tagsRaw string) map[string]string {
-	if tagsRaw == "" {
-		return nil
-	}
-
-	tags := map[string]string{}
-	fmt.Println("parsing tag:", tagsRaw)
-	for _, t := range strings.Split(strings.Trim(tagsRaw, "`"), " ") {
-		fmt.Println("tag:", t)
-		p := strings.Split(t, ":")
-		tags[p[0]] = p[1]
-	}
-
-	return tags
+	return fmt.Sprintf("`%s:%q`", tag, value)
 }
 
 type Imports struct {
@@ -174,18 +80,41 @@ func (i *Imports) Add(alias, stmt string) {
 	i.stmtsByAlias[alias] = stmt
 }
 
+// Alias returns the name the import path is referenced by, or an empty
+// string if the path is not imported
+func (i Imports) Alias(path string) string {
+	quoted := strconv.Quote(path)
+	for alias, stmt := range i.stmtsByAlias {
+		if strings.HasSuffix(stmt, quoted) {
+			return alias
+		}
+	}
+	return ""
+}
+
 func (i Imports) Empty() bool {
 	return len(i.stmts) == 0
 }
 
+// Include adds the statements of the aliases to the imports of the file,
+// once each
 func (i *Imports) Include(aliases ...string) {
 	for _, alias := range aliases {
-		if stmt, ok := i.stmtsByAlias[strings.TrimSpace(alias)]; ok {
+		if stmt, ok := i.stmtsByAlias[strings.TrimSpace(alias)]; ok && !i.included(stmt) {
 			i.stmts = append(i.stmts, stmt)
 		}
 	}
 }
 
+func (i Imports) included(stmt string) bool {
+	for _, s := range i.stmts {
+		if s == stmt {
+			return true
+		}
+	}
+	return false
+}
+
 func (i *Imports) Use(alias, stmt string) {
 	i.Add(alias, stmt)
 	i.Include(alias)
@@ -279,14 +208,6 @@ func lowerName(name string, delim, lhs, rhs string) string {
 	return fmt.Sprintf("%s%s%s", lhs, strings.Join(parts, delim), rhs)
 }
 
-func pkgName(importPath, srcDir string) string {
-	pkg, err := build.Import(importPath, srcDir, build.IgnoreVendor)
-	if err == nil {
-		return pkg.Name
-	}
-	return pkgNameFromImportPath(importPath)
-}
-
 func pkgNameFromImportPath(importPath string) string {
 	base := path.Base(importPath)
 	if strings.HasPrefix(base, "v") {
@@ -330,6 +251,34 @@ func privateName(args ...string) string {
 	return name
 }
 
+// initialisms are the words exported in upper case, as golint has them
+var initialisms = map[string]bool{
+	"API": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "TCP": true,
+	"TLS": true, "UDP": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "XML": true,
+}
+
+// exportedName capitalizes the first word of an unexported name, upper
+// casing it when it is an initialism, e.g. ID for id and URLPath for urlPath
+func exportedName(name string) string {
+	if token.IsExported(name) {
+		return name
+	}
+
+	parts := camelcase.Split(name)
+	if len(parts) == 0 {
+		return name
+	}
+
+	if upper := strings.ToUpper(parts[0]); initialisms[upper] {
+		parts[0] = upper
+	} else {
+		parts[0] = strings.Title(parts[0])
+	}
+	return strings.Join(parts, "")
+}
+
 func isGoWord(word string) bool {
 	switch word {
 	case "break",
@@ -0,0 +1,71 @@
+package inspect
+
+// Plan records the outputs a run generates, so that a model nested in
+// another is stored as the payload or document generated for it before the
+// file declaring that output exists
+type Plan struct {
+	outputs map[plannedOutput]OutputArgs
+}
+
+type plannedOutput struct {
+	pkgPath  string
+	typeName string
+	kind     string
+	tag      string
+}
+
+// OutputArgs are the flags an output is generated with, the args of its
+// marker and the options configured for it, e.g. -include=ID
+type OutputArgs struct {
+	Marker     []string
+	Configured []string
+}
+
+func NewPlan() *Plan {
+	return &Plan{outputs: map[plannedOutput]OutputArgs{}}
+}
+
+// Add records an output of the generator for the type declared in the
+// package, along with the args set so far. An empty package path matches
+// the type in any package, e.g. for the types configured by name when their
+// packages are not loaded.
+func (p *Plan) Add(pkgPath, typeName, kind, tag string, args OutputArgs) {
+	key := plannedOutput{pkgPath: pkgPath, typeName: typeName, kind: kind, tag: tag}
+	p.outputs[key] = p.outputs[key].merge(args)
+}
+
+// Has reports whether an output of the generator and tag is planned for the
+// referenced type
+func (p *Plan) Has(ref TypeRef, kind, tag string) bool {
+	_, ok := p.Args(ref.PkgPath(), ref.Name, kind, tag)
+	return ok
+}
+
+// Args returns the args of an output of the generator and tag planned for
+// the type declared in the package, reporting whether there is one
+func (p *Plan) Args(pkgPath, typeName, kind, tag string) (OutputArgs, bool) {
+	if p == nil {
+		return OutputArgs{}, false
+	}
+
+	var args OutputArgs
+	var found bool
+	for _, path := range []string{pkgPath, ""} {
+		if a, ok := p.outputs[plannedOutput{pkgPath: path, typeName: typeName, kind: kind, tag: tag}]; ok {
+			args = args.merge(a)
+			found = true
+		}
+	}
+	return args, found
+}
+
+// merge returns the args along with those set by other
+func (a OutputArgs) merge(other OutputArgs) OutputArgs {
+	if other.Marker != nil {
+		a.Marker = other.Marker
+	}
+	if other.Configured != nil {
+		a.Configured = other.Configured
+	}
+	return a
+}
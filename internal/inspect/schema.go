@@ -1,128 +1,344 @@
 package inspect
 
 import (
-	"bytes"
+	"fmt"
 	"go/ast"
-	"go/printer"
-	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
-type TypeFieldsOptions struct {
-	Target string
-	Pkg    string
-}
-
-func TypeFields(file *ParsedFile, opts TypeFieldsOptions) (fields []Field, err error) {
-	ast.Inspect(file.File, func(node ast.Node) bool {
-		if err != nil {
-			return false
-		}
-
-		t, ok := node.(*ast.TypeSpec)
-		if !ok {
-			return true
-		}
+// TypeFields collects the fields of the declared interface or struct type,
+// flattening the interfaces and structs it embeds unless a struct marks
+// them with a makes-code:nest comment
+func TypeFields(decl *TypeDecl) ([]Field, error) {
+	holder := decl.File.pkg.TypesInfo.Defs[decl.Spec.Name].Type()
 
-		if t.Name.Name != opts.Target {
-			return false
-		}
-
-		fields, err = collectTypeFields(file, opts, t)
-		return false
-	})
-	return
-}
-
-func collectTypeFields(file *ParsedFile, opts TypeFieldsOptions, t *ast.TypeSpec) ([]Field, error) {
 	var fields []field
 	var err error
 
-	if i, ok := t.Type.(*ast.InterfaceType); ok {
-		fields, err = collectInterfaceInfo(file, i)
-	}
-
-	if s, ok := t.Type.(*ast.StructType); ok {
-		fields, err = collectStructInfo(file, s)
+	switch t := decl.Spec.Type.(type) {
+	case *ast.InterfaceType:
+		fields, err = collectInterfaceInfo(decl.File, holder, t)
+	case *ast.StructType:
+		fields, err = collectStructInfo(decl.File, holder, t)
+	default:
+		return nil, fmt.Errorf("type %s is neither an interface nor a struct", decl.Spec.Name.Name)
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	q := decl.qualifier()
+
 	out := make([]Field, 0, len(fields))
+	seen := map[string]field{}
 	for _, f := range fields {
-		out = append(out, NewField(opts.Pkg, opts.Target, f.name, f.typeRaw, f.tagsRaw))
+		if prev, ok := seen[f.name]; ok {
+			if prev.declared != f.declared {
+				return nil, fmt.Errorf("%s: fields %s and %s are both exported as %s",
+					decl.Spec.Name.Name, prev.declared, f.declared, f.name)
+			}
+			if !types.Identical(prev.typ, f.typ) {
+				return nil, fmt.Errorf("%s: field %s is declared twice with the types %s and %s",
+					decl.Spec.Name.Name, f.name, prev.typ, f.typ)
+			}
+			continue
+		}
+		seen[f.name] = f
+
+		tagsRaw := strings.TrimSpace(f.tagsRaw + " " + f.annotations)
+		out = append(out, NewField(decl.Spec.Name.Name, f.name, newFieldType(f.typ, q), tagsRaw))
 	}
 
 	return out, nil
 }
 
+// DeclTypeParams returns the type parameters of the declared type, which
+// are empty unless it is generic
+func DeclTypeParams(decl *TypeDecl) TypeParams {
+	obj, ok := decl.File.pkg.TypesInfo.Defs[decl.Spec.Name].(*types.TypeName)
+	if !ok {
+		return nil
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+
+	q := decl.qualifier()
+
+	var params TypeParams
+	for i := 0; i < named.TypeParams().Len(); i++ {
+		p := named.TypeParams().At(i)
+		params = append(params, TypeParam{
+			Name:       p.Obj().Name(),
+			Constraint: newFieldType(p.Constraint(), q),
+		})
+	}
+	return params
+}
+
+// isModelMethod reports whether the name is that of a method the generated
+// model implements rather than of a field
+func isModelMethod(name string) bool {
+	return name == "Builder" || name == "Clone"
+}
+
+// field is a collected field, named as its model getter. The unexported
+// fields of a struct are exported since their getters would clash with the
+// fields of the generated data otherwise, declared keeping the original name.
 type field struct {
-	name    string
-	typeRaw string
-	tagsRaw string
+	name        string
+	declared    string
+	typ         types.Type
+	tagsRaw     string
+	annotations string
 }
 
-func collectInterfaceInfo(file *ParsedFile, i *ast.InterfaceType) ([]field, error) {
+// collectInterfaceInfo collects the methods of the interface as declared
+// by holder, which instantiates the types of a generic interface embedded
+// with type arguments. The file only provides the names and comments of the
+// methods, their types being those of the holder.
+func collectInterfaceInfo(file *ParsedFile, holder types.Type, i *ast.InterfaceType) ([]field, error) {
 	fields := make([]field, 0, len(i.Methods.List))
 
+	iface, _ := holder.Underlying().(*types.Interface)
+
+	var embeddedIndex int
 	for _, m := range i.Methods.List {
+		if len(m.Names) == 0 {
+			if isNested(m.Doc, m.Comment) {
+				return nil, fmt.Errorf("embedded interface %s cannot be nested, the model implements its methods",
+					types.ExprString(m.Type))
+			}
+
+			var typ types.Type
+			if iface != nil && embeddedIndex < iface.NumEmbeddeds() {
+				typ = iface.EmbeddedType(embeddedIndex)
+			}
+			embeddedIndex++
+
+			embedded, err := collectEmbedded(file, m.Type, typ, nil, m.Doc, m.Comment)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+
 		fieldName := m.Names[0].Name
 
-		if fieldName == "Builder" {
+		if isModelMethod(fieldName) {
 			continue
 		}
 
-		fieldType, err := parseType(file.tokens, m.Type)
-		if err != nil {
+		fn, ok := lookupMember(holder, fieldName).(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve method %s", fieldName)
+		}
+
+		// only getters are fields, while methods taking arguments such as
+		// the With methods of the model are left to its implementation
+		sig := fn.Type().(*types.Signature)
+		if sig.Params().Len() > 0 || sig.Results().Len() == 0 {
 			continue
 		}
-		// collectFieldInfo(ws, fieldName, fieldType, "")
-		fields = append(fields, field{name: fieldName, typeRaw: fieldType})
+		results := sig.Results()
+
+		fields = append(fields, field{
+			name:        fieldName,
+			declared:    fieldName,
+			typ:         results.At(0).Type(),
+			annotations: annotations(m.Doc, m.Comment),
+		})
 	}
 	return fields, nil
 }
 
-func collectStructInfo(file *ParsedFile, s *ast.StructType) ([]field, error) {
-	fields := make([]field, 0, len(s.Fields.List))
+// collectEmbedded collects the fields of an embedded interface or struct of
+// the given type, or the field holding it when a struct embeds it with a
+// makes-code:nest comment
+func collectEmbedded(
+	file *ParsedFile,
+	expr ast.Expr,
+	typ types.Type,
+	tag *ast.BasicLit,
+	groups ...*ast.CommentGroup,
+) ([]field, error) {
+	if typ == nil {
+		return nil, fmt.Errorf("failed to resolve embedded type %s", types.ExprString(expr))
+	}
 
-	for _, f := range s.Fields.List {
-		fieldName := f.Names[0].Name
+	elem := typ
+	if p, ok := typ.(*types.Pointer); ok {
+		elem = p.Elem()
+	}
+
+	named, ok := types.Unalias(elem).(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("embedded type %s is not supported", types.ExprString(expr))
+	}
+
+	if isNested(groups...) {
+		var fieldTag string
+		if tag != nil {
+			fieldTag, _ = strconv.Unquote(tag.Value)
+		}
+		return []field{{
+			name:        exportedName(named.Obj().Name()),
+			declared:    named.Obj().Name(),
+			typ:         typ,
+			tagsRaw:     fieldTag,
+			annotations: annotations(groups...),
+		}}, nil
+	}
+
+	declFile, spec, err := findDecl(file, named.Origin().Obj())
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("failed to find the declaration of embedded type %s", types.ExprString(expr))
+	}
+
+	switch t := spec.Type.(type) {
+	case *ast.InterfaceType:
+		return collectInterfaceInfo(declFile, named, t)
+	case *ast.StructType:
+		return collectStructInfo(declFile, named, t)
+	}
+	return nil, fmt.Errorf("embedded type %s is neither an interface nor a struct", types.ExprString(expr))
+}
+
+// lookupMember returns the method or field of the holder type, which may be
+// unexported when declared in the package of the holder
+func lookupMember(holder types.Type, name string) types.Object {
+	var pkg *types.Package
+	if named, ok := types.Unalias(holder).(*types.Named); ok {
+		pkg = named.Obj().Pkg()
+	}
+	obj, _, _ := types.LookupFieldOrMethod(holder, true, pkg, name)
+	return obj
+}
+
+// isNested reports whether the comments mark an embedded type to be kept as
+// a field rather than flattened, e.g.
+//
+//	// makes-code:nest
+//	Entity
+func isNested(groups ...*ast.CommentGroup) bool {
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, c := range g.List {
+			if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == markerPrefix+"nest" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findDecl returns the file and spec declaring the named type, parsing the
+// package declaring it when it is not the package of the file
+func findDecl(file *ParsedFile, obj *types.TypeName) (*ParsedFile, *ast.TypeSpec, error) {
+	if obj.Pkg() == nil {
+		return nil, nil, nil
+	}
+
+	pkg, err := importedPackage(file, obj.Pkg().Path())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, f := range pkg.Files {
+		for _, spec := range fileTypeSpecs(f) {
+			if spec.Name.Name == obj.Name() {
+				return f, spec, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
 
-		if fieldName == "Builder" {
+var annotationPattern = regexp.MustCompile(`^([A-Za-z_][\w.-]*):\s*"`)
+
+// annotations collects the comment lines written as struct tags, which may
+// leave a space after the key, e.g.
+//
+//	// validate:"required,max=64"
+//	// default: "guest"
+//	Name() string
+func annotations(groups ...*ast.CommentGroup) string {
+	var tags []string
+	for _, g := range groups {
+		if g == nil {
 			continue
 		}
+		for _, c := range g.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if annotationPattern.MatchString(text) {
+				tags = append(tags, annotationPattern.ReplaceAllString(text, `$1:"`))
+			}
+		}
+	}
+	return strings.Join(tags, " ")
+}
+
+// collectStructInfo collects the fields of the struct as declared by
+// holder, which instantiates the types of a generic struct embedded with
+// type arguments
+func collectStructInfo(file *ParsedFile, holder types.Type, s *ast.StructType) ([]field, error) {
+	fields := make([]field, 0, len(s.Fields.List))
+
+	st, _ := holder.Underlying().(*types.Struct)
+
+	var fieldIndex int
+	for _, f := range s.Fields.List {
+		if len(f.Names) == 0 {
+			var typ types.Type
+			if st != nil && fieldIndex < st.NumFields() {
+				typ = st.Field(fieldIndex).Type()
+			}
+			fieldIndex++
 
-		fieldType, err := parseType(file.tokens, f.Type)
-		if err != nil {
+			embedded, err := collectEmbedded(file, f.Type, typ, f.Tag, f.Doc, f.Comment)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
 			continue
 		}
 
 		var fieldTag string
 		if f.Tag != nil {
-			fieldTag = f.Tag.Value
+			fieldTag, _ = strconv.Unquote(f.Tag.Value)
 		}
 
-		fields = append(fields, field{fieldName, fieldType, fieldTag})
-	}
-	return fields, nil
-}
+		for _, n := range f.Names {
+			fieldIndex++
 
-// func collectFieldInfo(pkgName, modelName, fieldName, fieldType, fieldTag string) Field {
-// 	f := NewField(pkgName, modelName, fieldName, fieldType, fieldTag)
-// 	ws.Fields = append(ws.Fields, f)
-// 	for _, i := range f.Type.Imports() {
-// 		ws.Imports.Include(i)
-// 	}
-// }
+			if n.Name == "_" || isModelMethod(n.Name) {
+				continue
+			}
 
-func parseType(fs *token.FileSet, node ast.Node) (string, error) {
-	out := new(bytes.Buffer)
-	if err := printer.Fprint(out, fs, node); err != nil {
-		return "", err
-	}
+			v, ok := lookupMember(holder, n.Name).(*types.Var)
+			if !ok {
+				return nil, fmt.Errorf("failed to resolve field %s", n.Name)
+			}
 
-	outStr := out.String()
-	return outStr[strings.Index(outStr, ")")+1:], nil
+			fields = append(fields, field{
+				name:     exportedName(n.Name),
+				declared: n.Name,
+				typ:      v.Type(),
+				tagsRaw:  fieldTag,
+			})
+		}
+	}
+	return fields, nil
 }
@@ -0,0 +1,124 @@
+package inspect
+
+import (
+	"strings"
+	"testing"
+)
+
+func loadDecl(t *testing.T, dir, name string) *TypeDecl {
+	t.Helper()
+
+	pkgs, err := LoadPackages(dir, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decl, err := FindType(pkgs, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decl
+}
+
+// fieldTypes formats the fields as name type pairs
+func fieldTypes(fields []Field) []string {
+	out := make([]string, len(fields))
+	for i, f := range fields {
+		out[i] = f.Names.Public + " " + f.Type.String()
+	}
+	return out
+}
+
+func assertFields(t *testing.T, fields []Field, want ...string) {
+	t.Helper()
+
+	got := fieldTypes(fields)
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("fields = %q, want %q", got, want)
+	}
+}
+
+func TestTypeFieldsFlattensEmbeddedInterfaces(t *testing.T) {
+	decl := loadDecl(t, "testdata/embed", "User")
+
+	fields, err := TypeFields(decl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFields(t, fields,
+		"ID string",
+		"Created time.Time",
+		"Items []string",
+		"Nick string",
+		"Name string",
+	)
+
+	if rules, _ := fields[1].Lookup("validate"); rules != "required" {
+		t.Errorf("Created validate = %q, want the annotation of the embedded method", rules)
+	}
+}
+
+func TestTypeFieldsFlattensEmbeddedStructs(t *testing.T) {
+	decl := loadDecl(t, "testdata/embed", "accountSpec")
+
+	fields, err := TypeFields(decl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFields(t, fields,
+		"CreatedBy string",
+		"Tags []string",
+		"Extra *Extra",
+		"Plan string",
+	)
+
+	if name := fields[0].Tags["json"].Name; name != "created_by" {
+		t.Errorf("CreatedBy json name = %q, want created_by", name)
+	}
+	if name := fields[2].Tags["json"].Name; name != "extra" {
+		t.Errorf("Extra json name = %q, want the tag of the nested field", name)
+	}
+}
+
+func TestTypeFieldsDedupesIdenticalFields(t *testing.T) {
+	fields, err := TypeFields(loadDecl(t, "testdata/embed", "Twice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFields(t, fields, "ID string", "Created time.Time")
+}
+
+func TestTypeFieldsRejects(t *testing.T) {
+	for name, want := range map[string]string{
+		"clashSpec": "field Tags is declared twice",
+		"Nested":    "cannot be nested",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := TypeFields(loadDecl(t, "testdata/embed", name))
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("err = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestTypeFieldsExportsStructFields(t *testing.T) {
+	fields, err := TypeFields(loadDecl(t, "testdata/source", "userSpec"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFields(t, fields, "ID string", "URLPath string", "Name string")
+
+	if private := fields[0].Names.Private; private != "id" {
+		t.Errorf("ID private name = %q, want id", private)
+	}
+}
+
+func TestTypeFieldsRejectsFieldsExportedAlike(t *testing.T) {
+	_, err := TypeFields(loadDecl(t, "testdata/source", "ambiguousSpec"))
+	if want := "fields id and ID are both exported as ID"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,110 @@
+package inspect
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Tags are the struct tags of a field by key
+type Tags map[string]TagValue
+
+// TagValue is a struct tag value split into its name and options, e.g.
+// json:"name,omitempty"
+type TagValue struct {
+	Raw     string
+	Name    string
+	Options []string
+}
+
+// Has reports whether the tag value holds the option
+func (v TagValue) Has(option string) bool {
+	for _, o := range v.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Skip reports whether the tag value excludes the field, as in json:"-"
+// but not json:"-," naming the field -
+func (v TagValue) Skip() bool {
+	return v.Raw == "-"
+}
+
+// With returns a copy of the tags setting the key to the value
+func (t Tags) With(key string, value TagValue) Tags {
+	out := make(Tags, len(t)+1)
+	for k, v := range t {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+// ParseTags parses a struct tag following the reflect.StructTag conventions,
+// keeping the first value of repeated keys and stopping at the first
+// malformed pair
+func ParseTags(tag string) Tags {
+	var tags Tags
+
+	for tag != "" {
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		quoted := tag[:i+1]
+		tag = tag[i+1:]
+
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			break
+		}
+
+		if tags == nil {
+			tags = Tags{}
+		}
+		if _, ok := tags[key]; !ok {
+			tags[key] = newTagValue(value)
+		}
+	}
+
+	return tags
+}
+
+func newTagValue(raw string) TagValue {
+	parts := strings.Split(raw, ",")
+
+	v := TagValue{Raw: raw, Name: parts[0]}
+	for _, o := range parts[1:] {
+		if o = strings.TrimSpace(o); o != "" {
+			v.Options = append(v.Options, o)
+		}
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	for _, tt := range []struct {
+		tag  string
+		want Tags
+	}{
+		{``, nil},
+		{`json:"name,omitempty" validate:"min=1 max=5"`, Tags{
+			"json":     {Raw: "name,omitempty", Name: "name", Options: []string{"omitempty"}},
+			"validate": {Raw: "min=1 max=5", Name: "min=1 max=5"},
+		}},
+		{`  json:"a"   bson:",inline"`, Tags{
+			"json": {Raw: "a", Name: "a"},
+			"bson": {Raw: ",inline", Options: []string{"inline"}},
+		}},
+		{`json:"first" json:"second"`, Tags{"json": {Raw: "first", Name: "first"}}},
+		{`json:"a\"b"`, Tags{"json": {Raw: `a"b`, Name: `a"b`}}},
+		{`json:"a" broken xml:"b"`, Tags{"json": {Raw: "a", Name: "a"}}},
+		{`json:name`, nil},
+		{`json:"unterminated`, nil},
+		{`:"value"`, nil},
+		{`json:"-"`, Tags{"json": {Raw: "-", Name: "-"}}},
+		{`json:"-,"`, Tags{"json": {Raw: "-,", Name: "-"}}},
+		{`json:",omitempty, string"`, Tags{"json": {Raw: ",omitempty, string", Options: []string{"omitempty", "string"}}}},
+	} {
+		got := ParseTags(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%s) = %#v, want %#v", tt.tag, got, tt.want)
+		}
+
+		// the values found agree with reflect.StructTag
+		for key, value := range got {
+			if raw, ok := reflect.StructTag(tt.tag).Lookup(key); !ok || raw != value.Raw {
+				t.Errorf("ParseTags(%s)[%s] = %q, reflect.StructTag has %q", tt.tag, key, value.Raw, raw)
+			}
+		}
+	}
+}
+
+func TestTagValueSkip(t *testing.T) {
+	for raw, want := range map[string]bool{
+		"-":           true,
+		"-,":          false,
+		"-,omitempty": false,
+		"name":        false,
+		"":            false,
+	} {
+		if got := newTagValue(raw).Skip(); got != want {
+			t.Errorf("Skip of %q = %t, want %t", raw, got, want)
+		}
+	}
+}
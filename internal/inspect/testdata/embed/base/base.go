@@ -0,0 +1,31 @@
+package base
+
+import (
+	"html/template"
+	"time"
+)
+
+// Entity is embedded by the models stored with an identifier
+type Entity interface {
+	ID() string
+	// validate:"required"
+	Created() time.Time
+}
+
+type Paged[T any] interface {
+	Items() []T
+}
+
+type Audit struct {
+	CreatedBy string `json:"created_by"`
+	Tags      []string
+}
+
+type Page interface {
+	Body() template.HTML
+}
+
+// makes-code:payload tag=Partial
+type Profile interface {
+	Bio() string
+}
@@ -0,0 +1,50 @@
+package embed
+
+import (
+	"text/template"
+
+	"github.com/makes-code/gen/internal/inspect/testdata/embed/base"
+)
+
+type User interface {
+	base.Entity
+	base.Paged[string]
+	Named
+	Name() string
+}
+
+type Named interface {
+	Nick() string
+}
+
+type accountSpec struct {
+	base.Audit
+	*Extra `json:"extra"` // makes-code:nest
+	Plan   string
+}
+
+type Extra struct{ Note string }
+
+type Doc interface {
+	base.Page
+	Tmpl() *template.Template
+}
+
+type Twice interface {
+	base.Entity
+	ID() string
+}
+
+type clashSpec struct {
+	base.Audit
+	Tags string
+}
+
+type Nested interface {
+	// makes-code:nest
+	base.Entity
+}
+
+type Member interface {
+	Profile() base.Profile
+}
@@ -0,0 +1,13 @@
+package pending
+
+import "github.com/makes-code/gen/internal/inspect/testdata/embed/base"
+
+type User interface {
+	base.Entity
+	Name() string
+}
+
+// UserBuilder is yet to be generated
+func (b *UserBuilder) Prebuild() error {
+	return nil
+}
@@ -0,0 +1,13 @@
+package source
+
+type userSpec struct {
+	id      string
+	urlPath string
+	Name    string
+	_       struct{}
+}
+
+type ambiguousSpec struct {
+	id string
+	ID string
+}
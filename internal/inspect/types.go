@@ -0,0 +1,561 @@
+package inspect
+
+import (
+	"fmt"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type FieldType interface {
+	fmt.Stringer
+	Imports() []string
+	Kind() TypeKind
+	// Elem returns the element type of pointers, slices, arrays and maps
+	Elem() FieldType
+	// Key returns the key type of maps
+	Key() FieldType
+	// Named returns the reference to a named type
+	Named() (TypeRef, bool)
+	// Basic returns the name of the underlying basic type, e.g. int64 for
+	// a time.Duration, or an empty string
+	Basic() string
+	// Underlying returns the type a named type is declared as, e.g. []byte
+	// for a net.IP, or the type itself
+	Underlying() FieldType
+}
+
+// TypeKind classifies a field type by its underlying type
+type TypeKind int
+
+const (
+	KindOther TypeKind = iota
+	KindString
+	KindNumber
+	KindBool
+	KindSlice
+	KindArray
+	KindMap
+	KindNilable
+)
+
+func typeKind(t types.Type) TypeKind {
+	if _, ok := t.(*types.TypeParam); ok {
+		return KindOther
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch info := u.Info(); {
+		case info&types.IsString != 0:
+			return KindString
+		case info&types.IsNumeric != 0:
+			return KindNumber
+		case info&types.IsBoolean != 0:
+			return KindBool
+		case u.Kind() == types.UnsafePointer:
+			return KindNilable
+		}
+	case *types.Slice:
+		return KindSlice
+	case *types.Array:
+		return KindArray
+	case *types.Map:
+		return KindMap
+	case *types.Pointer, *types.Interface, *types.Chan, *types.Signature:
+		return KindNilable
+	}
+	return KindOther
+}
+
+func newFieldType(t types.Type, q *typeQualifier) FieldType {
+	switch tt := t.(type) {
+	case *types.Basic:
+		return scalarFieldType{name: tt.Name(), kind: typeKind(tt)}
+	case *types.Named:
+		return newScalarFieldType(tt, tt.Obj(), tt.TypeArgs(), q)
+	case *types.Alias:
+		return newScalarFieldType(tt, tt.Obj(), tt.TypeArgs(), q)
+	case *types.Pointer:
+		return pointerFieldType{newFieldType(tt.Elem(), q)}
+	case *types.Slice:
+		return arrayFieldType{-1, newFieldType(tt.Elem(), q)}
+	case *types.Array:
+		return arrayFieldType{tt.Len(), newFieldType(tt.Elem(), q)}
+	case *types.Map:
+		return mapFieldType{newFieldType(tt.Key(), q), newFieldType(tt.Elem(), q)}
+	}
+
+	var imports []string
+	name := types.TypeString(t, func(p *types.Package) string {
+		alias := q.alias(p)
+		if alias != "" {
+			imports = append(imports, alias)
+		}
+		return alias
+	})
+	return exprFieldType{name, imports, typeKind(t)}
+}
+
+func newScalarFieldType(
+	tt types.Type,
+	obj *types.TypeName,
+	args *types.TypeList,
+	q *typeQualifier,
+) scalarFieldType {
+	t := scalarFieldType{
+		ref:  &TypeRef{Pkg: q.alias(obj.Pkg()), Name: obj.Name(), obj: obj, q: q},
+		typ:  tt,
+		kind: typeKind(tt),
+	}
+	for i := 0; i < args.Len(); i++ {
+		t.args = append(t.args, newFieldType(args.At(i), q))
+	}
+	t.ref.args = t.args
+	return t
+}
+
+// TypeRef references a named type from the generated file
+type TypeRef struct {
+	Pkg  string
+	Name string
+
+	obj  *types.TypeName
+	args []FieldType
+	q    *typeQualifier
+}
+
+// TypeArgs returns the type arguments of an instantiated generic type, e.g.
+// [user.Identity] for a Page[user.Identity], or an empty string
+func (r TypeRef) TypeArgs() string {
+	if len(r.args) == 0 {
+		return ""
+	}
+	args := make([]string, len(r.args))
+	for i, arg := range r.args {
+		args[i] = arg.String()
+	}
+	return "[" + strings.Join(args, ", ") + "]"
+}
+
+// Args returns the type arguments of an instantiated generic type
+func (r TypeRef) Args() []FieldType {
+	return r.args
+}
+
+// Qualified returns the name as referenced from the generated file when it
+// is declared in the package of the type
+func (r TypeRef) Qualified(name string) string {
+	if r.Pkg == "" {
+		return name
+	}
+	return r.Pkg + "." + name
+}
+
+// PkgPath returns the import path of the package declaring the type
+func (r TypeRef) PkgPath() string {
+	if p := r.obj.Pkg(); p != nil {
+		return p.Path()
+	}
+	return ""
+}
+
+// IsInterface reports whether the named type is an interface
+func (r TypeRef) IsInterface() bool {
+	return types.IsInterface(r.obj.Type())
+}
+
+// Declares reports whether the package of the type declares the name
+func (r TypeRef) Declares(name string) bool {
+	p := r.obj.Pkg()
+	return p != nil && p.Scope().Lookup(name) != nil
+}
+
+// HasMethod reports whether the type or a pointer to it has the method, or
+// whether the interface declares it
+func (r TypeRef) HasMethod(name string) bool {
+	t := r.obj.Type()
+	if !r.IsInterface() {
+		t = types.NewPointer(t)
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, true, r.obj.Pkg(), name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// Dir returns the directory of the package declaring the type, which the
+// code generated for it is written to
+func (r TypeRef) Dir() (string, error) {
+	p := r.obj.Pkg()
+	if p == nil {
+		return "", fmt.Errorf("type %s is not declared by a package", r.Name)
+	}
+
+	pkg, err := importedPackage(r.q.file, p.Path())
+	if err != nil {
+		return "", err
+	}
+	return pkg.Dir, nil
+}
+
+// Markers returns the makes-code markers declared on the type, parsing the
+// package declaring it when it is not the package of the model
+func (r TypeRef) Markers() ([]Marker, error) {
+	p := r.obj.Pkg()
+	if p == nil {
+		return nil, nil
+	}
+
+	pkg, err := importedPackage(r.q.file, p.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	var markers []Marker
+	for _, m := range PackageMarkers(pkg) {
+		if m.Decl.Spec.Name.Name == r.Name {
+			markers = append(markers, m)
+		}
+	}
+	return markers, nil
+}
+
+type scalarFieldType struct {
+	name string
+	ref  *TypeRef
+	typ  types.Type
+	args []FieldType
+	kind TypeKind
+}
+
+func (t scalarFieldType) String() string {
+	if t.ref == nil {
+		return t.name
+	}
+	return t.ref.Qualified(t.ref.Name) + t.ref.TypeArgs()
+}
+
+func (t scalarFieldType) Kind() TypeKind { return t.kind }
+
+// Elem returns the element type of named slices, arrays, maps and
+// pointers, e.g. byte for a net.IP
+func (t scalarFieldType) Elem() FieldType {
+	if t.ref == nil {
+		return nil
+	}
+	return t.Underlying().Elem()
+}
+
+func (t scalarFieldType) Key() FieldType {
+	if t.ref == nil {
+		return nil
+	}
+	return t.Underlying().Key()
+}
+
+// Underlying is resolved on demand, since a named type may reference
+// itself, e.g. type Tree map[string]Tree
+func (t scalarFieldType) Underlying() FieldType {
+	if t.ref == nil {
+		return t
+	}
+	return newFieldType(t.typ.Underlying(), t.ref.q)
+}
+
+func (t scalarFieldType) Named() (TypeRef, bool) {
+	if t.ref == nil {
+		return TypeRef{}, false
+	}
+	return *t.ref, true
+}
+
+func (t scalarFieldType) Basic() string {
+	if t.ref == nil {
+		return t.name
+	}
+	if b, ok := t.ref.obj.Type().Underlying().(*types.Basic); ok {
+		return b.Name()
+	}
+	return ""
+}
+
+func (t scalarFieldType) Imports() []string {
+	var imports []string
+	if t.ref != nil && t.ref.Pkg != "" {
+		imports = append(imports, t.ref.Pkg)
+	}
+	for _, arg := range t.args {
+		imports = append(imports, arg.Imports()...)
+	}
+	return imports
+}
+
+type pointerFieldType struct {
+	elemType FieldType
+}
+
+func (t pointerFieldType) String() string {
+	return "*" + t.elemType.String()
+}
+
+func (t pointerFieldType) Kind() TypeKind { return KindNilable }
+
+func (t pointerFieldType) Elem() FieldType { return t.elemType }
+
+func (t pointerFieldType) Key() FieldType { return nil }
+
+func (t pointerFieldType) Underlying() FieldType { return t }
+
+func (t pointerFieldType) Named() (TypeRef, bool) { return TypeRef{}, false }
+
+func (t pointerFieldType) Basic() string { return "" }
+
+func (t pointerFieldType) Imports() []string {
+	return t.elemType.Imports()
+}
+
+type arrayFieldType struct {
+	len      int64
+	elemType FieldType
+}
+
+func (t arrayFieldType) String() string {
+	if t.len < 0 {
+		return fmt.Sprintf("[]%s", t.elemType)
+	}
+	return fmt.Sprintf("[%d]%s", t.len, t.elemType)
+}
+
+func (t arrayFieldType) Kind() TypeKind {
+	if t.len < 0 {
+		return KindSlice
+	}
+	return KindArray
+}
+
+func (t arrayFieldType) Elem() FieldType { return t.elemType }
+
+func (t arrayFieldType) Key() FieldType { return nil }
+
+func (t arrayFieldType) Underlying() FieldType { return t }
+
+func (t arrayFieldType) Named() (TypeRef, bool) { return TypeRef{}, false }
+
+func (t arrayFieldType) Basic() string { return "" }
+
+func (t arrayFieldType) Imports() []string {
+	return t.elemType.Imports()
+}
+
+type mapFieldType struct {
+	keyType   FieldType
+	valueType FieldType
+}
+
+func (t mapFieldType) String() string {
+	return fmt.Sprintf("map[%s]%s", t.keyType, t.valueType)
+}
+
+func (t mapFieldType) Kind() TypeKind { return KindMap }
+
+func (t mapFieldType) Elem() FieldType { return t.valueType }
+
+func (t mapFieldType) Key() FieldType { return t.keyType }
+
+func (t mapFieldType) Underlying() FieldType { return t }
+
+func (t mapFieldType) Named() (TypeRef, bool) { return TypeRef{}, false }
+
+func (t mapFieldType) Basic() string { return "" }
+
+func (t mapFieldType) Imports() []string {
+	var imports []string
+	imports = append(imports, t.keyType.Imports()...)
+	imports = append(imports, t.valueType.Imports()...)
+	return imports
+}
+
+// exprFieldType holds types without further structure of interest to the
+// generators, such as channels, funcs and anonymous structs
+type exprFieldType struct {
+	name    string
+	imports []string
+	kind    TypeKind
+}
+
+func (t exprFieldType) String() string { return t.name }
+
+func (t exprFieldType) Imports() []string { return t.imports }
+
+func (t exprFieldType) Kind() TypeKind { return t.kind }
+
+func (t exprFieldType) Elem() FieldType { return nil }
+
+func (t exprFieldType) Key() FieldType { return nil }
+
+func (t exprFieldType) Underlying() FieldType { return t }
+
+func (t exprFieldType) Named() (TypeRef, bool) { return TypeRef{}, false }
+
+func (t exprFieldType) Basic() string { return "" }
+
+// TypeParam is a type parameter of a generic model, e.g. the T any of a
+// Page[T any]
+type TypeParam struct {
+	Name       string
+	Constraint FieldType
+}
+
+// TypeParams are the type parameters of a generic model, formatted as their
+// declaration, e.g. [K comparable, V any], or as an empty string when the
+// model is not generic
+type TypeParams []TypeParam
+
+func (p TypeParams) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+	params := make([]string, len(p))
+	for i, param := range p {
+		params[i] = param.Name + " " + param.Constraint.String()
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// Args returns the parameters as the type arguments instantiating the model
+// with them, e.g. [K, V]
+func (p TypeParams) Args() string {
+	if len(p) == 0 {
+		return ""
+	}
+	args := make([]string, len(p))
+	for i, param := range p {
+		args[i] = param.Name
+	}
+	return "[" + strings.Join(args, ", ") + "]"
+}
+
+// Has reports whether one of the parameters is named name
+func (p TypeParams) Has(name string) bool {
+	for _, param := range p {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Used returns the parameters referenced by the type expression, which a
+// func taking that type must declare
+func (p TypeParams) Used(expr string) TypeParams {
+	idents := strings.FieldsFunc(expr, func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var used TypeParams
+	for _, param := range p {
+		for _, ident := range idents {
+			if ident == param.Name {
+				used = append(used, param)
+				break
+			}
+		}
+	}
+	return used
+}
+
+func (p TypeParams) Imports() []string {
+	var imports []string
+	for _, param := range p {
+		imports = append(imports, param.Constraint.Imports()...)
+	}
+	return imports
+}
+
+// typeQualifier resolves the package alias used to reference a type from
+// within the file declaring the target. The packages the file does not
+// import, such as those of the types of flattened embedded fields, are
+// recorded under their name, or under an alias when the name is taken.
+type typeQualifier struct {
+	file    *ParsedFile
+	aliases map[string]string
+	extra   map[string]string
+}
+
+func newTypeQualifier(file *ParsedFile) *typeQualifier {
+	q := &typeQualifier{file: file, aliases: map[string]string{}, extra: map[string]string{}}
+	for _, i := range file.Imports {
+		path := strings.Trim(i.Path.Value, `"`)
+		switch {
+		case i.Name != nil:
+			q.aliases[path] = i.Name.Name
+		case file.pkg.TypesInfo != nil && file.pkg.TypesInfo.PkgNameOf(i) != nil:
+			q.aliases[path] = file.pkg.TypesInfo.PkgNameOf(i).Imported().Name()
+		default:
+			q.aliases[path] = pkgNameFromImportPath(path)
+		}
+	}
+	return q
+}
+
+func (q *typeQualifier) alias(p *types.Package) string {
+	if p == nil || p.Path() == q.file.pkg.PkgPath {
+		return ""
+	}
+	if alias, ok := q.aliases[p.Path()]; ok {
+		if alias == "." {
+			return ""
+		}
+		return alias
+	}
+	if alias, ok := q.extra[p.Path()]; ok {
+		return alias
+	}
+
+	alias := p.Name()
+	if q.taken(alias) {
+		// e.g. htmltemplate for a html/template clashing with text/template
+		alias = strings.Map(func(r rune) rune {
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, path.Base(path.Dir(p.Path()))) + p.Name()
+	}
+	for i := 2; q.taken(alias); i++ {
+		alias = fmt.Sprintf("%s%d", p.Name(), i)
+	}
+	q.extra[p.Path()] = alias
+	return alias
+}
+
+// taken reports whether an import of the file or a recorded package uses
+// the alias, or whether the file declares it
+func (q *typeQualifier) taken(alias string) bool {
+	for _, a := range q.aliases {
+		if a == alias {
+			return true
+		}
+	}
+	for _, a := range q.extra {
+		if a == alias {
+			return true
+		}
+	}
+	return q.file.pkg.Types != nil && q.file.pkg.Types.Scope().Lookup(alias) != nil
+}
+
+// imports returns the import statements of the recorded packages
+func (q *typeQualifier) imports() map[string]string {
+	stmts := make(map[string]string, len(q.extra))
+	for p, alias := range q.extra {
+		stmt := strconv.Quote(p)
+		if alias != pkgNameFromImportPath(p) {
+			stmt = alias + " " + stmt
+		}
+		stmts[alias] = stmt
+	}
+	return stmts
+}
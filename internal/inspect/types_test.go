@@ -0,0 +1,29 @@
+package inspect
+
+import (
+	"testing"
+)
+
+func TestTypeRefMarkersOfImportedType(t *testing.T) {
+	fields, err := TypeFields(loadDecl(t, "testdata/embed", "Member"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFields(t, fields, "Profile base.Profile")
+
+	ref, ok := fields[0].Type.Named()
+	if !ok {
+		t.Fatalf("type %s is not named", fields[0].Type)
+	}
+
+	markers, err := ref.Markers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markers) != 1 || markers[0].Kind != "payload" {
+		t.Fatalf("markers = %+v, want the payload marker of base.Profile", markers)
+	}
+	if tag, _ := markers[0].Arg("tag"); tag != "Partial" {
+		t.Errorf("tag = %q, want Partial", tag)
+	}
+}
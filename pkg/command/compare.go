@@ -0,0 +1,150 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+// modelComparer compares the model fields by value, e.g. the Identities()
+// []user.Identity field of a user is compared with slices.Equal and its
+// Profile() user.Profile with the Equal method of the nested model. Nil and
+// empty slices and maps are equal. Values of types without structure of
+// interest, such as structs and interfaces, are compared with
+// reflect.DeepEqual.
+type modelComparer struct {
+	prefix  string
+	imports []string
+}
+
+func newModelComparer(model inspect.Names) *modelComparer {
+	return &modelComparer{prefix: "equal" + model.Public}
+}
+
+func (c *modelComparer) use(pkg string) {
+	if !contains(c.imports, pkg) {
+		c.imports = append(c.imports, pkg)
+	}
+}
+
+// funcs returns the expression comparing each field, formatted with the two
+// values, along with the funcs comparing the slices, maps and pointers the
+// expression does not
+func (c *modelComparer) funcs(fields []inspect.Field, params inspect.TypeParams) ([]string, []tmplConverter) {
+	var comparers []tmplConverter
+
+	exprs := make([]string, len(fields))
+	for i, f := range fields {
+		if expr, ok := c.expr(f.Type, "%[1]s", "%[2]s"); ok {
+			exprs[i] = expr
+			continue
+		}
+
+		name := c.prefix + f.Names.Public
+		exprs[i] = name + "(%[1]s, %[2]s)"
+
+		var body strings.Builder
+		c.equal(&body, f.Type, "a", "b", 0)
+
+		comparers = append(comparers, tmplConverter{
+			Name:       name,
+			TypeParams: params.Used(f.Type.String()),
+			In:         f.Type.String(),
+			Body:       body.String(),
+		})
+	}
+	return exprs, comparers
+}
+
+// expr returns the expression comparing a and b, reporting false when the
+// values need the statements written by equal
+func (c *modelComparer) expr(t inspect.FieldType, a, b string) (string, bool) {
+	if ref, ok := t.Named(); ok && ref.HasMethod("Equal") {
+		if ref.IsInterface() {
+			return fmt.Sprintf("(%[1]s == nil) == (%[2]s == nil) && (%[1]s == nil || %[1]s.Equal(%[2]s))", a, b), true
+		}
+		return fmt.Sprintf("%s.Equal(%s)", a, b), true
+	}
+
+	switch t.Kind() {
+	case inspect.KindString, inspect.KindNumber, inspect.KindBool:
+		return fmt.Sprintf("%s == %s", a, b), true
+	case inspect.KindSlice:
+		if isComparable(t.Elem()) {
+			c.use("slices")
+			return fmt.Sprintf("slices.Equal(%s, %s)", a, b), true
+		}
+	case inspect.KindMap:
+		if isComparable(t.Elem()) {
+			c.use("maps")
+			return fmt.Sprintf("maps.Equal(%s, %s)", a, b), true
+		}
+	case inspect.KindArray:
+		if isComparable(t.Elem()) {
+			return fmt.Sprintf("%s == %s", a, b), true
+		}
+	}
+
+	if t.Elem() != nil {
+		return "", false
+	}
+
+	c.use("reflect")
+	return fmt.Sprintf("reflect.DeepEqual(%s, %s)", a, b), true
+}
+
+// isComparable reports whether values of the type are equal when == holds
+func isComparable(t inspect.FieldType) bool {
+	if ref, ok := t.Named(); ok && ref.HasMethod("Equal") {
+		return false
+	}
+
+	switch t.Kind() {
+	case inspect.KindString, inspect.KindNumber, inspect.KindBool:
+		return true
+	case inspect.KindArray:
+		return isComparable(t.Elem())
+	}
+	return false
+}
+
+// equal writes the statements returning false unless a equals b, ending
+// with return true at the top level
+func (c *modelComparer) equal(sb *strings.Builder, t inspect.FieldType, a, b string, depth int) {
+	defer func() {
+		if depth == 0 {
+			sb.WriteString("return true\n")
+		}
+	}()
+
+	if expr, ok := c.expr(t, a, b); ok {
+		fmt.Fprintf(sb, "if !(%s) {\nreturn false\n}\n", expr)
+		return
+	}
+
+	i, k, v, w := fmt.Sprintf("i%d", depth), fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth), fmt.Sprintf("w%d", depth)
+
+	switch t.Kind() {
+	case inspect.KindSlice:
+		fmt.Fprintf(sb, "if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", i, v, a)
+		c.equal(sb, t.Elem(), v, b+"["+i+"]", depth+1)
+		sb.WriteString("}\n")
+	case inspect.KindArray:
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", i, v, a)
+		c.equal(sb, t.Elem(), v, b+"["+i+"]", depth+1)
+		sb.WriteString("}\n")
+	case inspect.KindMap:
+		fmt.Fprintf(sb, "if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", k, v, a)
+		fmt.Fprintf(sb, "%s, ok := %s[%s]\nif !ok {\nreturn false\n}\n", w, b, k)
+		c.equal(sb, t.Elem(), v, w, depth+1)
+		sb.WriteString("}\n")
+	case inspect.KindNilable:
+		fmt.Fprintf(sb, "if (%s == nil) != (%s == nil) {\nreturn false\n}\n", a, b)
+		fmt.Fprintf(sb, "if %s != nil {\n", a)
+		c.equal(sb, t.Elem(), "(*"+a+")", "(*"+b+")", depth+1)
+		sb.WriteString("}\n")
+	}
+}
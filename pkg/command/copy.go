@@ -0,0 +1,132 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+// tmplModelField is a model field along with the funcs copying, cloning and
+// comparing it, when it needs more than an assignment or ==. Equal formats
+// the expression comparing the two values given as arguments. Required
+// fields are tracked by the Bit of the builder mask.
+type tmplModelField struct {
+	inspect.Field
+	Copy     string
+	Clone    string
+	Equal    string
+	Required bool
+	Bit      int
+}
+
+// modelCopier copies the slices and maps of the model fields, e.g. the
+// Identities() []user.Identity field of a user is copied by a generated
+// copyUserIdentities func, so that copies share no backing arrays. Deep
+// copies, named clones, also copy the values of pointers and clone the
+// nested models declaring a Clone method.
+type modelCopier struct {
+	prefix string
+	deep   bool
+}
+
+func newModelCopier(model inspect.Names, deep bool) modelCopier {
+	prefix := "copy"
+	if deep {
+		prefix = "clone"
+	}
+	return modelCopier{prefix: prefix + model.Public, deep: deep}
+}
+
+// funcs returns the name of the func copying each field, empty when an
+// assignment copies it, along with the funcs
+func (c modelCopier) funcs(fields []inspect.Field, params inspect.TypeParams) ([]string, []tmplConverter) {
+	var copiers []tmplConverter
+
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		if !c.needsCopy(f.Type) {
+			continue
+		}
+
+		names[i] = c.prefix + f.Names.Public
+
+		var body strings.Builder
+		c.copy(&body, f.Type, "in", "out", 0)
+
+		copiers = append(copiers, tmplConverter{
+			Name:       names[i],
+			TypeParams: params.Used(f.Type.String()),
+			In:         f.Type.String(),
+			Out:        f.Type.String(),
+			Body:       body.String(),
+		})
+	}
+	return names, copiers
+}
+
+// needsCopy reports whether values of the type share slices or maps when
+// assigned, or pointers and nested models for deep copies
+func (c modelCopier) needsCopy(t inspect.FieldType) bool {
+	switch t.Kind() {
+	case inspect.KindSlice, inspect.KindMap:
+		return true
+	case inspect.KindArray:
+		return c.needsCopy(t.Elem())
+	case inspect.KindNilable:
+		return c.deep && (t.Elem() != nil || isCloneable(t))
+	}
+	return false
+}
+
+// isCloneable reports whether the type is a model declaring a Clone method
+func isCloneable(t inspect.FieldType) bool {
+	ref, ok := t.Named()
+	return ok && ref.IsInterface() && ref.HasMethod("Clone")
+}
+
+// copy writes the statements copying in to out, keeping nil slices, maps
+// and pointers nil
+func (c modelCopier) copy(sb *strings.Builder, t inspect.FieldType, in, out string, depth int) {
+	if !c.needsCopy(t) {
+		fmt.Fprintf(sb, "%s = %s\n", out, in)
+		return
+	}
+
+	i, v, o := fmt.Sprintf("i%d", depth), fmt.Sprintf("v%d", depth), fmt.Sprintf("o%d", depth)
+
+	switch t.Kind() {
+	case inspect.KindSlice:
+		fmt.Fprintf(sb, "if %s != nil {\n%s = make(%s, len(%s))\n", in, out, t, in)
+		if !c.needsCopy(t.Elem()) {
+			fmt.Fprintf(sb, "copy(%s, %s)\n}\n", out, in)
+			return
+		}
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", i, v, in)
+		c.copy(sb, t.Elem(), v, out+"["+i+"]", depth+1)
+		sb.WriteString("}\n}\n")
+	case inspect.KindArray:
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", i, v, in)
+		c.copy(sb, t.Elem(), v, out+"["+i+"]", depth+1)
+		sb.WriteString("}\n")
+	case inspect.KindMap:
+		k := fmt.Sprintf("k%d", depth)
+		fmt.Fprintf(sb, "if %s != nil {\n%s = make(%s, len(%s))\n", in, out, t, in)
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", k, v, in)
+		if !c.needsCopy(t.Elem()) {
+			fmt.Fprintf(sb, "%s[%s] = %s\n}\n}\n", out, k, v)
+			return
+		}
+		fmt.Fprintf(sb, "var %s %s\n", o, t.Elem())
+		c.copy(sb, t.Elem(), v, o, depth+1)
+		fmt.Fprintf(sb, "%s[%s] = %s\n}\n}\n", out, k, o)
+	case inspect.KindNilable:
+		if t.Elem() == nil {
+			fmt.Fprintf(sb, "if %s != nil {\n%s = %s.Clone()\n}\n", in, out, in)
+			return
+		}
+		fmt.Fprintf(sb, "if %s != nil {\nvar %s %s\n", in, o, t.Elem())
+		c.copy(sb, t.Elem(), "(*"+in+")", o, depth+1)
+		fmt.Fprintf(sb, "%s = &%s\n}\n", out, o)
+	}
+}
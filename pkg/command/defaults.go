@@ -0,0 +1,133 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+const defaultTag = "default"
+
+// tmplDefault is the value a new builder sets a field to
+type tmplDefault struct {
+	Field inspect.Field
+	Value string
+}
+
+// fieldDefaults compiles the defaults declared on the fields, e.g.
+// default:"guest", into the values set by the generated builder. Slices
+// take comma separated values and, like maps, default to non-nil empty
+// values when given none.
+func fieldDefaults(model inspect.Names, fields []inspect.Field) ([]tmplDefault, error) {
+	var defaults []tmplDefault
+
+	for _, f := range fields {
+		value, ok := f.Lookup(defaultTag)
+		if !ok {
+			continue
+		}
+
+		d, err := newDefault(f, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", model.Public, f.Names.Public, err)
+		}
+		defaults = append(defaults, d)
+	}
+
+	return defaults, nil
+}
+
+func newDefault(f inspect.Field, value string) (tmplDefault, error) {
+	switch f.Type.Kind() {
+	case inspect.KindSlice:
+		if value == "" {
+			return tmplDefault{Field: f, Value: f.Type.String() + "{}"}, nil
+		}
+
+		values := strings.Split(value, ",")
+		elems := make([]string, len(values))
+		for i, v := range values {
+			elem, err := literal(f.Type.Elem(), strings.TrimSpace(v))
+			if err != nil {
+				return tmplDefault{}, err
+			}
+			elems[i] = elem
+		}
+		return tmplDefault{Field: f, Value: fmt.Sprintf("%s{%s}", f.Type, strings.Join(elems, ", "))}, nil
+
+	case inspect.KindMap:
+		if value != "" {
+			return tmplDefault{}, fmt.Errorf("maps only default to an empty map, got %q", value)
+		}
+		return tmplDefault{Field: f, Value: f.Type.String() + "{}"}, nil
+	}
+
+	v, err := literal(f.Type, value)
+	if err != nil {
+		return tmplDefault{}, err
+	}
+	return tmplDefault{Field: f, Value: v}, nil
+}
+
+// literal returns the Go expression of the value of a string, number, bool
+// or time.Duration type
+func literal(t inspect.FieldType, value string) (string, error) {
+	if ref, ok := t.Named(); ok && ref.PkgPath() == "time" && ref.Name == "Duration" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "", fmt.Errorf("default expects a duration, got %q", value)
+		}
+		return fmt.Sprintf("%s(%d)", t, d), nil
+	}
+
+	switch t.Kind() {
+	case inspect.KindString:
+		return strconv.Quote(value), nil
+	case inspect.KindNumber:
+		if !isNumber(t.Basic(), value) {
+			return "", fmt.Errorf("default expects a number of type %s, got %q", t.Basic(), value)
+		}
+		return value, nil
+	case inspect.KindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("default expects a bool, got %q", value)
+		}
+		return strconv.FormatBool(b), nil
+	}
+	return "", fmt.Errorf("default values are not supported for %s", t)
+}
+
+// isNumber reports whether the value is a Go literal of the basic numeric
+// type that fits in it, e.g. 255 but neither 256 nor 1.5 for a uint8, and
+// a finite number for floats
+func isNumber(basic, value string) bool {
+	switch basic {
+	case "byte":
+		basic = "uint8"
+	case "rune":
+		basic = "int32"
+	}
+
+	bits := 64
+	if i := strings.IndexAny(basic, "0123456789"); i >= 0 {
+		bits, _ = strconv.Atoi(basic[i:])
+	}
+
+	switch {
+	case strings.HasPrefix(basic, "uint"):
+		_, err := strconv.ParseUint(value, 0, bits)
+		return err == nil
+	case strings.HasPrefix(basic, "int"):
+		_, err := strconv.ParseInt(value, 0, bits)
+		return err == nil
+	case strings.HasPrefix(basic, "float"):
+		f, err := strconv.ParseFloat(value, bits)
+		return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package command
+
+import (
+	"testing"
+)
+
+func TestIsNumber(t *testing.T) {
+	for _, tt := range []struct {
+		basic, value string
+		want         bool
+	}{
+		{"int", "-42", true},
+		{"int", "0x2A", true},
+		{"int", "1_000", true},
+		{"int", "1.5", false},
+		{"int8", "127", true},
+		{"int8", "128", false},
+		{"uint8", "255", true},
+		{"uint8", "256", false},
+		{"uint", "-1", false},
+		{"byte", "255", true},
+		{"rune", "2147483648", false},
+		{"float32", "1.5", true},
+		{"float32", "1e39", false},
+		{"float64", "1e-3", true},
+		{"float64", "NaN", false},
+		{"float64", "Inf", false},
+		{"float64", "-inf", false},
+		{"complex128", "1", false},
+	} {
+		if got := isNumber(tt.basic, tt.value); got != tt.want {
+			t.Errorf("isNumber(%s, %q) = %t, want %t", tt.basic, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestModelDefaultsNameInvalidFields(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+type User interface {
+	Name() string
+	// default: "300"
+	Level() uint8
+}
+`,
+	})
+
+	err := generate(t, typeModel(), "User", "-dry-run")
+	if want := `User.Level: default expects a number of type uint8, got "300"`; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
+
+func TestModelDefaults(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+import "time"
+
+type Level uint8
+
+type Tags []string
+
+type Scores map[string]int
+
+// makes-code:model
+type User interface {
+	// default: "guest"
+	Role() string
+	// default: "0x10"
+	Level() Level
+	// default: "-1.5"
+	Score() float32
+	// default: "true"
+	Active() bool
+	// default: "90s"
+	Timeout() time.Duration
+	// default: "a, b"
+	Tags() []string
+	// default: "admin"
+	Labels() Tags
+	// default: ""
+	Scores() Scores
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+		"user_test.go": `package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaults(t *testing.T) {
+	u := NewUserBuilder().MustBuild()
+
+	got := []interface{}{u.Role(), u.Level(), u.Score(), u.Active(), u.Timeout(), u.Tags(), u.Labels(), u.Scores()}
+	want := []interface{}{"guest", Level(16), float32(-1.5), true, 90 * time.Second, []string{"a", "b"}, Tags{"admin"}, Scores{}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("defaults = %v, want %v", got, want)
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+}
@@ -0,0 +1,32 @@
+package command
+
+import (
+	"github.com/makes-code/gen/internal/cli"
+
+	mcli "github.com/mitchellh/cli"
+)
+
+// builtinTemplates holds the generator templates by generator name, which is
+// also the name a user template overriding it takes in a templates directory
+var builtinTemplates = map[string]string{
+	"model":        tmplModel,
+	"payload":      tmplPayload,
+	"payload-ts":   tmplPayloadTS,
+	"document":     tmplDocument,
+	"proto":        tmplProto,
+	"proto-schema": tmplProtoSchema,
+	"row":          tmplRow,
+	"repository":   tmplRepository,
+	"schema":       tmplSchema,
+}
+
+func ExportTemplates() (mcli.Command, error) {
+	return &cli.CmdExportTemplates{
+		CmdMeta: cli.CmdMeta{
+			Name:     "export-templates",
+			Help:     "Write the built-in generator templates to a directory as a starting point for custom templates",
+			Synopsis: "Export the built-in generator templates",
+		},
+		Templates: builtinTemplates,
+	}, nil
+}
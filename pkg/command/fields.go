@@ -0,0 +1,184 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/makes-code/gen/internal/inspect"
+	"github.com/makes-code/gen/internal/utils"
+)
+
+// fieldFilter selects and renames the model fields written by a generator
+type fieldFilter struct {
+	include utils.StringArray
+	exclude utils.StringArray
+	strict  bool
+}
+
+func (ff *fieldFilter) flags(fs *flag.FlagSet) {
+	fs.Var(&ff.include, "i", "")
+	fs.Var(&ff.include, "include", "")
+	fs.Var(&ff.exclude, "x", "")
+	fs.Var(&ff.exclude, "exclude", "")
+	fs.BoolVar(&ff.strict, "strict", false, "")
+}
+
+// set reports whether any of the flags is set
+func (ff fieldFilter) set() bool {
+	return len(ff.include) > 0 || len(ff.exclude) > 0 || ff.strict
+}
+
+// tagOptions are the struct tag options supported by each encoding
+var tagOptions = map[string][]string{
+	"json": {"omitempty", "string", "-"},
+	"bson": {"omitempty", "inline", "minsize", "-"},
+}
+
+// apply returns the selected fields, naming each one after its include
+// override, its source tag, its entry in defaults or its system name, in that
+// order. Fields whose source tag is "-" are left out unless included, and
+// include options such as -i Name=n,omitempty are added to the source ones.
+func (ff fieldFilter) apply(fields []inspect.Field, tag string, defaults map[string]string) ([]inspect.Field, error) {
+	includes, includesErr := parseIncludes(ff.include)
+	if includesErr != nil {
+		return nil, includesErr
+	}
+
+	whitelist := map[string]include{}
+	for _, i := range includes {
+		for _, o := range i.options {
+			if !contains(tagOptions[tag], o) {
+				return nil, fmt.Errorf("include option %q of %s is not supported by %s", o, i.name, tag)
+			}
+		}
+		whitelist[i.name] = i
+	}
+
+	blacklist := map[string]struct{}{}
+	for _, f := range splitList(ff.exclude) {
+		blacklist[f] = struct{}{}
+	}
+
+	known := map[string]struct{}{}
+	for _, field := range fields {
+		known[field.Names.Public] = struct{}{}
+	}
+
+	for name := range whitelist {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("include references unknown field %q", name)
+		}
+	}
+
+	for name := range blacklist {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("exclude references unknown field %q", name)
+		}
+	}
+
+	var out []inspect.Field
+	for _, field := range fields {
+		if _, ok := blacklist[field.Names.Public]; ok {
+			continue
+		}
+
+		i, ok := whitelist[field.Names.Public]
+		if !ok && ff.strict {
+			continue
+		}
+
+		source := field.Tags[tag]
+		if !ok && source.Skip() {
+			continue
+		}
+
+		if name, ok := defaults[field.Names.Public]; ok {
+			field.Names.Field = name
+		}
+
+		if source.Name != "" && source.Name != "-" {
+			field.Names.Field = source.Name
+		}
+
+		if i.override != "" {
+			field.Names.Field = i.override
+		}
+
+		if len(i.options) > 0 {
+			value := source
+			value.Options = append([]string(nil), source.Options...)
+			for _, o := range i.options {
+				if !value.Has(o) {
+					value.Options = append(value.Options, o)
+				}
+			}
+			field.Tags = field.Tags.With(tag, value)
+		}
+
+		out = append(out, field)
+	}
+	return out, nil
+}
+
+// include is a field selected by the include flag, e.g. Name=n,omitempty
+type include struct {
+	name     string
+	override string
+	options  []string
+}
+
+// parseIncludes reads the include flag values, where the list items naming
+// a tag option apply to the field preceding them
+func parseIncludes(values []string) ([]include, error) {
+	var includes []include
+
+	for _, item := range splitList(values) {
+		if isTagOption(item) {
+			if len(includes) == 0 {
+				return nil, fmt.Errorf("include option %q does not follow a field", item)
+			}
+			last := &includes[len(includes)-1]
+			last.options = append(last.options, item)
+			continue
+		}
+
+		i := include{name: item}
+		if parts := strings.SplitN(item, "=", 2); len(parts) == 2 {
+			i.name, i.override = parts[0], parts[1]
+		}
+		includes = append(includes, i)
+	}
+
+	return includes, nil
+}
+
+func isTagOption(item string) bool {
+	for _, opts := range tagOptions {
+		if contains(opts, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+func TestParseIncludes(t *testing.T) {
+	for _, tt := range []struct {
+		values []string
+		want   []include
+		err    string
+	}{
+		{values: nil, want: nil},
+		{values: []string{"Name=n,omitempty"}, want: []include{
+			{name: "Name", override: "n", options: []string{"omitempty"}},
+		}},
+		{values: []string{"Name=n,omitempty,string", "Age", "ID,-"}, want: []include{
+			{name: "Name", override: "n", options: []string{"omitempty", "string"}},
+			{name: "Age"},
+			{name: "ID", options: []string{"-"}},
+		}},
+		{values: []string{" Name , inline "}, want: []include{
+			{name: "Name", options: []string{"inline"}},
+		}},
+		{values: []string{"Name", "omitempty"}, want: []include{
+			{name: "Name", options: []string{"omitempty"}},
+		}},
+		{values: []string{"omitempty,Name"}, err: `include option "omitempty" does not follow a field`},
+		{values: []string{"-"}, err: `include option "-" does not follow a field`},
+	} {
+		got, err := parseIncludes(tt.values)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("parseIncludes(%q) returned %v, want %s", tt.values, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseIncludes(%q): %s", tt.values, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseIncludes(%q) = %+v, want %+v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestFieldFilterApply(t *testing.T) {
+	fields := []inspect.Field{
+		inspect.NewField("User", "ID", nil, `json:"-" bson:"_id"`),
+		inspect.NewField("User", "Name", nil, `json:"full_name,string"`),
+		inspect.NewField("User", "Age", nil, ``),
+		inspect.NewField("User", "Profile", nil, `bson:",inline"`),
+	}
+
+	for _, tt := range []struct {
+		name    string
+		include []string
+		exclude []string
+		strict  bool
+		tag     string
+		want    []string
+		err     string
+	}{
+		{
+			name: "json tags",
+			tag:  "json",
+			want: []string{"`json:\"full_name,string\"`", "`json:\"age\"`", "`json:\"profile\"`"},
+		},
+		{
+			name: "bson tags",
+			tag:  "bson",
+			want: []string{"`bson:\"_id\"`", "`bson:\"name\"`", "`bson:\"age\"`", "`bson:\"profile,inline\"`"},
+		},
+		{
+			name:    "override and option",
+			include: []string{"Name=n,omitempty"},
+			tag:     "json",
+			want:    []string{"`json:\"n,string,omitempty\"`", "`json:\"age\"`", "`json:\"profile\"`"},
+		},
+		{
+			name:    "strict",
+			include: []string{"Age,omitempty"},
+			strict:  true,
+			tag:     "json",
+			want:    []string{"`json:\"age,omitempty\"`"},
+		},
+		{
+			name:    "include a skipped field",
+			include: []string{"ID=id"},
+			strict:  true,
+			tag:     "json",
+			want:    []string{"`json:\"id\"`"},
+		},
+		{
+			name:    "skip option",
+			include: []string{"Age,-"},
+			tag:     "json",
+			want:    []string{"`json:\"full_name,string\"`", "`json:\"-\"`", "`json:\"profile\"`"},
+		},
+		{
+			name:    "bson options",
+			include: []string{"Profile,omitempty", "Age,minsize"},
+			exclude: []string{"ID", "Name"},
+			tag:     "bson",
+			want:    []string{"`bson:\"age,minsize\"`", "`bson:\"profile,inline,omitempty\"`"},
+		},
+		{
+			name:    "inline on json",
+			include: []string{"Profile,inline"},
+			tag:     "json",
+			err:     `include option "inline" of Profile is not supported by json`,
+		},
+		{
+			name:    "string on bson",
+			include: []string{"Age,string"},
+			tag:     "bson",
+			err:     `include option "string" of Age is not supported by bson`,
+		},
+		{
+			name:    "unknown include",
+			include: []string{"Email"},
+			tag:     "json",
+			err:     `include references unknown field "Email"`,
+		},
+		{
+			name:    "unknown exclude",
+			exclude: []string{"Email"},
+			tag:     "json",
+			err:     `exclude references unknown field "Email"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ff := fieldFilter{include: tt.include, exclude: tt.exclude, strict: tt.strict}
+			out, err := ff.apply(fields, tt.tag, nil)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("apply returned %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, len(out))
+			for i, f := range out {
+				got[i] = f.Tag(tt.tag)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply tags:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	// the include options are added to copies of the source tags
+	if got := fields[1].Tags["json"]; !reflect.DeepEqual(got.Options, []string{"string"}) {
+		t.Errorf("apply changed the source field tag to %+v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"github.com/makes-code/gen/internal/cli"
+
+	mcli "github.com/mitchellh/cli"
+)
+
+func Gen() (mcli.Command, error) {
+	return &cli.CmdBatch{
+		CmdMeta: cli.CmdMeta{
+			Name:     "gen",
+			Help:     "Generate the code requested by makes-code markers in the packages",
+			Synopsis: "Generate the code requested by makes-code markers",
+		},
+		Generators: map[string]func() *cli.CmdCodegen{
+			"model":      typeModel,
+			"payload":    typePayload,
+			"document":   typeDocument,
+			"proto":      typeProto,
+			"row":        typeRow,
+			"repository": typeRepository,
+			"schema":     typeSchema,
+		},
+	}, nil
+}
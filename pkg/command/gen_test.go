@@ -0,0 +1,275 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/makes-code/gen/internal/cli"
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+// writeModule writes the files of a module named example.com/app into a
+// temporary directory and makes it the working directory. The go.mod file
+// is written unless given.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if _, ok := files["go.mod"]; !ok {
+		files["go.mod"] = "module example.com/app\n\ngo 1.25\n"
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Chdir(dir)
+	return dir
+}
+
+// repoModule returns the go.mod and go.sum files of a module named
+// example.com/app requiring the dependencies of this one, for the modules
+// written by writeModule to build the generated code importing them
+func repoModule(t *testing.T) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	for _, name := range []string{"go.mod", "go.sum"} {
+		src, err := os.ReadFile(filepath.Join("..", "..", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[name] = string(src)
+	}
+
+	module, _, _ := strings.Cut(files["go.mod"], "\n")
+	files["go.mod"] = strings.Replace(files["go.mod"], module, "module example.com/app", 1)
+	return files
+}
+
+func runGen(t *testing.T, args ...string) {
+	t.Helper()
+
+	gen, err := Gen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := gen.Run(args); code != 0 {
+		t.Fatalf("gen %s exited with %d", strings.Join(args, " "), code)
+	}
+}
+
+// generate runs the generator for the type declared in the working
+// directory, returning its error
+func generate(t *testing.T, cmd *cli.CmdCodegen, typeName string, args ...string) error {
+	t.Helper()
+
+	pkgs, err := inspect.LoadPackages(".", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decl, err := inspect.FindType(pkgs, typeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Generate(decl)
+}
+
+func TestGenCheckConfiguredNestedModels(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"makes-code.yaml": "types:\n  User:\n    payload: {}\n  Profile:\n    payload: {}\n",
+		"user.go": `package app
+
+type User interface {
+	Name() string
+	Profile() Profile
+}
+
+type Profile interface {
+	Bio() string
+}
+`,
+	})
+
+	runGen(t)
+
+	src, err := os.ReadFile(filepath.Join(dir, "user_gen_payload.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "*ProfilePayload") {
+		t.Errorf("the User payload does not store the Profile payload:\n%s", src)
+	}
+
+	runGen(t, "-check")
+
+	if code := typePayload().Run([]string{"-name", "User", "-check"}); code != 0 {
+		t.Errorf("type payload -check exited with %d", code)
+	}
+}
+
+func TestGenMergesMarkerAndConfiguredOutputs(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"makes-code.yaml": "types:\n  User:\n    payload:\n      include: [Name, Age]\n",
+		"user.go": `package app
+
+// makes-code:payload i=Name strict
+type User interface {
+	Name() string
+	Age() int
+	Email() string
+}
+`,
+	})
+
+	runGen(t)
+
+	src, err := os.ReadFile(filepath.Join(dir, "user_gen_payload.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(src), "type UserPayload struct"); n != 1 {
+		t.Errorf("the User payload is declared %d times", n)
+	}
+	if !strings.Contains(string(src), "Age ") {
+		t.Errorf("the configured include does not override the marker one:\n%s", src)
+	}
+	if strings.Contains(string(src), "Email ") {
+		t.Errorf("the payload includes a field neither selects:\n%s", src)
+	}
+
+	runGen(t, "-check")
+}
+
+func TestGenRejectsDuplicateOutputs(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:payload tag=Partial
+// makes-code:payload tag=Partial strict
+type User interface {
+	Name() string
+}
+`,
+	})
+
+	gen, err := Gen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := gen.Run(nil); code == 0 {
+		t.Error("gen generated the same output twice")
+	}
+}
+
+func TestGenCheckStaleFiles(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:payload
+type User interface {
+	Name() string
+}
+`,
+	})
+
+	gen, err := Gen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check reports the missing file, which diff and dry-run leave missing
+	path := filepath.Join(dir, "user_gen_payload.go")
+	if code := gen.Run([]string{"-check"}); code == 0 {
+		t.Error("gen -check exited with 0 before the payload is generated")
+	}
+	for _, arg := range []string{"-diff", "-dry-run"} {
+		if code := gen.Run([]string{arg}); code != 0 {
+			t.Errorf("gen %s exited with %d", arg, code)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("gen wrote %s", path)
+	}
+
+	runGen(t)
+	runGen(t, "-check")
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the interface changes without regenerating the payload
+	err = os.WriteFile(filepath.Join(dir, "user.go"), []byte(`package app
+
+// makes-code:payload
+type User interface {
+	Name() string
+	Age() int
+}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := gen.Run([]string{"-check"}); code == 0 {
+		t.Error("gen -check exited with 0 for a stale payload")
+	}
+	if code := typePayload().Run([]string{"-name", "User", "-check"}); code == 0 {
+		t.Error("type payload -check exited with 0 for a stale payload")
+	}
+	for _, arg := range []string{"-diff", "-dry-run"} {
+		if code := gen.Run([]string{arg}); code != 0 {
+			t.Errorf("gen %s exited with %d", arg, code)
+		}
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(src) {
+		t.Errorf("-check, -diff or -dry-run rewrote %s", path)
+	}
+
+	runGen(t)
+	runGen(t, "-check")
+}
+
+func TestGenRejectsSingleTemplate(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"model.tmpl": "package {{.Pkg}}\n",
+		"user.go": `package app
+
+// makes-code:payload
+type User interface {
+	Name() string
+}
+`,
+	})
+
+	gen, err := Gen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := gen.Run([]string{"-template", "model.tmpl"}); code == 0 {
+		t.Error("gen -template exited with 0")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "user_gen_payload.go")); !os.IsNotExist(err) {
+		t.Error("gen -template generated the payload")
+	}
+}
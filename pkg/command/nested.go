@@ -0,0 +1,204 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+// tmplEncodedField is a payload or document field, stored as the payload or
+// document of its nested models
+type tmplEncodedField struct {
+	inspect.Field
+	EncodedType string
+	Encode      string
+	Decode      string
+}
+
+// tmplConverter is a generated func converting a field between its model
+// and encoded types, returning an error as well when Fails is set. The func
+// declares the type parameters of a generic model its types reference.
+type tmplConverter struct {
+	Name       string
+	TypeParams inspect.TypeParams
+	In         string
+	Out        string
+	Body       string
+	Fails      bool
+}
+
+// modelEncoder converts the fields holding nested models to the payload or
+// document generated for them, e.g. a Profile() user.Profile field of the
+// Partial user payload is stored as a *user.ProfilePayloadPartial. A model
+// is nested when its package declares the To<Model><Suffix> func or when it
+// is marked for the same generator and tag, or when the run plans that
+// output, e.g. from the config. The first error looking up the markers is
+// kept in err.
+type modelEncoder struct {
+	kind   string
+	tag    string
+	suffix string
+	prefix string
+	plan   *inspect.Plan
+	err    error
+}
+
+func newModelEncoder(kind, tag string, data inspect.Data) *modelEncoder {
+	suffix := strings.Title(kind) + tag
+	return &modelEncoder{
+		kind:   kind,
+		tag:    tag,
+		suffix: suffix,
+		prefix: data.Names.Public + suffix,
+		plan:   data.Plan,
+	}
+}
+
+func (e *modelEncoder) fields(fields []inspect.Field, params inspect.TypeParams) ([]tmplEncodedField, []tmplConverter, error) {
+	var converters []tmplConverter
+
+	out := make([]tmplEncodedField, len(fields))
+	for i, f := range fields {
+		out[i] = tmplEncodedField{Field: f, EncodedType: f.Type.String()}
+
+		encoded, ok := e.encodedType(f.Type)
+		if !ok {
+			continue
+		}
+
+		out[i].EncodedType = encoded
+		out[i].Encode = "encode" + e.prefix + f.Names.Public
+		out[i].Decode = "decode" + e.prefix + f.Names.Public
+
+		var encode, decode strings.Builder
+		e.encode(&encode, f.Type, "in", "out", 0)
+		e.decode(&decode, f.Type, "in", "out", 0)
+
+		used := params.Used(f.Type.String())
+		converters = append(converters,
+			tmplConverter{Name: out[i].Encode, TypeParams: used, In: f.Type.String(), Out: encoded, Body: encode.String()},
+			tmplConverter{Name: out[i].Decode, TypeParams: used, In: encoded, Out: f.Type.String(), Body: decode.String()},
+		)
+	}
+	return out, converters, e.err
+}
+
+func (e *modelEncoder) nested(t inspect.FieldType) (inspect.TypeRef, bool) {
+	ref, ok := t.Named()
+	if !ok || !ref.IsInterface() {
+		return ref, false
+	}
+
+	if ref.Declares("To"+ref.Name+e.suffix) || e.plan.Has(ref, e.kind, e.tag) {
+		return ref, true
+	}
+
+	markers, err := ref.Markers()
+	if err != nil {
+		if e.err == nil {
+			e.err = err
+		}
+		return ref, false
+	}
+
+	for _, m := range markers {
+		if tag, _ := m.Arg("tag"); m.Kind == e.kind && tag == e.tag {
+			return ref, true
+		}
+	}
+	return ref, false
+}
+
+// encodedType returns the type storing t, reporting whether it differs
+func (e *modelEncoder) encodedType(t inspect.FieldType) (string, bool) {
+	if ref, ok := e.nested(t); ok {
+		return "*" + ref.Qualified(ref.Name+e.suffix) + ref.TypeArgs(), true
+	}
+
+	// named slices, arrays and maps are stored as the unnamed types
+	// holding their encoded elements
+	switch t.Kind() {
+	case inspect.KindSlice, inspect.KindArray:
+		if elem, ok := e.encodedType(t.Elem()); ok {
+			s := t.Underlying().String()
+			return s[:strings.Index(s, "]")+1] + elem, true
+		}
+	case inspect.KindMap:
+		if elem, ok := e.encodedType(t.Elem()); ok {
+			return fmt.Sprintf("map[%s]%s", t.Key(), elem), true
+		}
+	case inspect.KindNilable:
+		if t.Elem() == nil {
+			break
+		}
+		if elem, ok := e.encodedType(t.Elem()); ok {
+			return "*" + elem, true
+		}
+	}
+	return t.String(), false
+}
+
+func (e *modelEncoder) encode(sb *strings.Builder, t inspect.FieldType, in, out string, depth int) {
+	if ref, ok := e.nested(t); ok {
+		fmt.Fprintf(sb, "if %s != nil {\n%s = %s(%s)\n}\n", in, out, ref.Qualified("To"+ref.Name+e.suffix), in)
+		return
+	}
+	e.convert(sb, t, in, out, depth, true)
+}
+
+func (e *modelEncoder) decode(sb *strings.Builder, t inspect.FieldType, in, out string, depth int) {
+	if _, ok := e.nested(t); ok {
+		fmt.Fprintf(sb, "if %s != nil {\n%s = %s\n}\n", in, out, in)
+		return
+	}
+	e.convert(sb, t, in, out, depth, false)
+}
+
+// convert writes the statements encoding or decoding the elements of
+// slices, arrays, maps and pointers
+func (e *modelEncoder) convert(sb *strings.Builder, t inspect.FieldType, in, out string, depth int, encoding bool) {
+	if _, ok := e.encodedType(t); !ok {
+		fmt.Fprintf(sb, "%s = %s\n", out, in)
+		return
+	}
+
+	elem := e.decode
+	if encoding {
+		elem = e.encode
+	}
+
+	// converted returns the type of the values written to out
+	converted := func(t inspect.FieldType) string {
+		if !encoding {
+			return t.String()
+		}
+		encoded, _ := e.encodedType(t)
+		return encoded
+	}
+
+	i, v, o := fmt.Sprintf("i%d", depth), fmt.Sprintf("v%d", depth), fmt.Sprintf("o%d", depth)
+
+	switch t.Kind() {
+	case inspect.KindSlice:
+		fmt.Fprintf(sb, "if %s != nil {\n%s = make(%s, len(%s))\n", in, out, converted(t), in)
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", i, v, in)
+		elem(sb, t.Elem(), v, out+"["+i+"]", depth+1)
+		sb.WriteString("}\n}\n")
+	case inspect.KindArray:
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", i, v, in)
+		elem(sb, t.Elem(), v, out+"["+i+"]", depth+1)
+		sb.WriteString("}\n")
+	case inspect.KindMap:
+		k := fmt.Sprintf("k%d", depth)
+		fmt.Fprintf(sb, "if %s != nil {\n%s = make(%s, len(%s))\n", in, out, converted(t), in)
+		fmt.Fprintf(sb, "for %s, %s := range %s {\n", k, v, in)
+		fmt.Fprintf(sb, "var %s %s\n", o, converted(t.Elem()))
+		elem(sb, t.Elem(), v, o, depth+1)
+		fmt.Fprintf(sb, "%s[%s] = %s\n}\n}\n", out, k, o)
+	case inspect.KindNilable:
+		fmt.Fprintf(sb, "if %s != nil {\nvar %s %s\n", in, o, converted(t.Elem()))
+		elem(sb, t.Elem(), "(*"+in+")", o, depth+1)
+		fmt.Fprintf(sb, "%s = &%s\n}\n", out, o)
+	}
+}
@@ -0,0 +1,440 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+// protoGoImports are the packages of the well-known types used by the proto
+// converters, by alias
+var protoGoImports = map[string]string{
+	"timestamppb": `"google.golang.org/protobuf/types/known/timestamppb"`,
+	"durationpb":  `"google.golang.org/protobuf/types/known/durationpb"`,
+}
+
+// protoBasics maps the Go basic types to their protobuf scalar and the Go
+// type protoc-gen-go generates for it
+var protoBasics = map[string][2]string{
+	"bool":    {"bool", "bool"},
+	"string":  {"string", "string"},
+	"int":     {"int64", "int64"},
+	"int64":   {"int64", "int64"},
+	"int8":    {"int32", "int32"},
+	"int16":   {"int32", "int32"},
+	"int32":   {"int32", "int32"},
+	"rune":    {"int32", "int32"},
+	"uint":    {"uint64", "uint64"},
+	"uint64":  {"uint64", "uint64"},
+	"uintptr": {"uint64", "uint64"},
+	"uint8":   {"uint32", "uint32"},
+	"byte":    {"uint32", "uint32"},
+	"uint16":  {"uint32", "uint32"},
+	"uint32":  {"uint32", "uint32"},
+	"float32": {"float", "float32"},
+	"float64": {"double", "float64"},
+}
+
+// tmplProtoField is a message field along with the expressions converting
+// it from the model and back
+type tmplProtoField struct {
+	inspect.Field
+	Number    int
+	Label     string
+	ProtoType string
+	GoName    string
+	To        string
+	From      string
+	Fails     bool
+}
+
+// protoType is the protobuf encoding of a Go type. The to and from formats
+// convert a value to the Go type generated by protoc and back, and from
+// returns an error as well when fails is set.
+type protoType struct {
+	name    string
+	goType  string
+	to      string
+	from    string
+	fails   bool
+	imports []string
+	schema  string
+}
+
+func (t protoType) identity() bool {
+	return t.to == "%s" && t.from == "%s"
+}
+
+// protoMapper maps the model fields to the fields of a protobuf message,
+// converting the models nested in the same package through their own
+// generated proto converters
+type protoMapper struct {
+	tag     string
+	model   inspect.Names
+	proto   string
+	encoder *modelEncoder
+}
+
+func newProtoMapper(tag, proto string, data inspect.Data) protoMapper {
+	return protoMapper{
+		tag:     tag,
+		model:   data.Names,
+		proto:   proto,
+		encoder: newModelEncoder("proto", tag, data),
+	}
+}
+
+// field maps a model field, returning the converters of its slices, maps
+// and pointers along with the imports and proto schemas it requires
+func (m protoMapper) field(f inspect.Field) (tmplProtoField, []tmplConverter, []string, []string, error) {
+	out := tmplProtoField{Field: f, GoName: goCamelCase(f.Names.Field)}
+	value := "%s." + f.Names.Public + "()"
+	getter := "msg.Get" + out.GoName + "()"
+	prefix := m.model.Public + "Proto" + m.tag + f.Names.Public
+
+	t := f.Type
+	if t.String() == "[]byte" || t.String() == "[]uint8" {
+		out.ProtoType = "bytes"
+		out.To, out.From = value, getter
+		return out, nil, nil, nil, nil
+	}
+
+	var pt protoType
+	var converters []tmplConverter
+	var err error
+
+	// named slices, maps and pointers as well as interfaces are mapped as a
+	// single value
+	kind := t.Kind()
+	if _, ok := t.Named(); ok || t.Elem() == nil {
+		kind = inspect.KindOther
+	}
+
+	switch kind {
+	case inspect.KindSlice:
+		if pt, err = m.scalar(t.Elem()); err != nil {
+			return out, nil, nil, nil, err
+		}
+		out.Label = "repeated"
+		out.ProtoType = pt.name
+		if !pt.identity() {
+			converters = m.sliceConverters(prefix, t, pt)
+		}
+
+	case inspect.KindMap:
+		key, keyErr := m.scalar(t.Key())
+		if keyErr != nil {
+			return out, nil, nil, nil, keyErr
+		}
+		switch key.name {
+		case "string", "bool", "int32", "int64", "uint32", "uint64":
+		default:
+			return out, nil, nil, nil, fmt.Errorf("map keys of type %s are not supported by protobuf", t.Key())
+		}
+		if pt, err = m.scalar(t.Elem()); err != nil {
+			return out, nil, nil, nil, err
+		}
+		pt.imports = append(pt.imports, key.imports...)
+		out.ProtoType = fmt.Sprintf("map<%s, %s>", key.name, pt.name)
+		if !pt.identity() || !key.identity() {
+			converters = m.mapConverters(prefix, t, key, pt)
+		}
+
+	case inspect.KindNilable:
+		if t.Elem().Basic() == "" {
+			break
+		}
+		if pt, err = m.scalar(t.Elem()); err != nil {
+			return out, nil, nil, nil, err
+		}
+		out.Label = "optional"
+		out.ProtoType = pt.name
+		if !pt.identity() {
+			converters = m.pointerConverters(prefix, t, pt)
+		}
+
+	default:
+		if pt, err = m.scalar(t); err != nil {
+			return out, nil, nil, nil, err
+		}
+		out.ProtoType = pt.name
+		out.To = fmt.Sprintf(pt.to, value)
+		out.From = fmt.Sprintf(pt.from, getter)
+		out.Fails = pt.fails
+	}
+
+	if out.ProtoType == "" {
+		return out, nil, nil, nil, fmt.Errorf("type %s is not supported by protobuf", t)
+	}
+
+	if out.To == "" {
+		out.To, out.From, out.Fails = value, getter, false
+		if len(converters) > 0 {
+			out.To = converters[0].Name + "(" + value + ")"
+			out.From = converters[1].Name + "(" + getter + ")"
+			out.Fails = converters[1].Fails
+		}
+	}
+
+	var schemas []string
+	if pt.schema != "" {
+		schemas = append(schemas, pt.schema)
+	}
+	return out, converters, pt.imports, schemas, nil
+}
+
+// scalar maps a type stored as a single protobuf value
+func (m protoMapper) scalar(t inspect.FieldType) (protoType, error) {
+	if ref, ok := t.Named(); ok {
+		switch {
+		case ref.PkgPath() == "time" && ref.Name == "Time":
+			return protoType{
+				name:    "google.protobuf.Timestamp",
+				goType:  "*timestamppb.Timestamp",
+				to:      "timestamppb.New(%s)",
+				from:    "%s.AsTime()",
+				imports: []string{"timestamppb"},
+				schema:  "google/protobuf/timestamp.proto",
+			}, nil
+		case ref.PkgPath() == "time" && ref.Name == "Duration":
+			return protoType{
+				name:    "google.protobuf.Duration",
+				goType:  "*durationpb.Duration",
+				to:      "durationpb.New(%s)",
+				from:    "%s.AsDuration()",
+				imports: []string{"durationpb"},
+				schema:  "google/protobuf/duration.proto",
+			}, nil
+		}
+
+		if _, ok := m.encoder.nested(t); ok {
+			if ref.Pkg != "" {
+				return protoType{}, fmt.Errorf("nested model %s must be declared in the package of %s", t, m.model.Public)
+			}
+			system := inspect.NewNames(ref.Name, inspect.NamesOptions{}).System
+			return protoType{
+				name:   ref.Name + m.tag,
+				goType: "*" + m.proto + "." + ref.Name + m.tag,
+				to:     "To" + ref.Name + "Proto" + m.tag + "(%s)",
+				from:   ref.Name + "FromProto" + m.tag + "(%s)",
+				fails:  true,
+				schema: protoFileName(system, m.tag),
+			}, nil
+		}
+	}
+
+	basic, ok := protoBasics[t.Basic()]
+	if !ok {
+		return protoType{}, fmt.Errorf("type %s is not supported by protobuf", t)
+	}
+
+	pt := protoType{name: basic[0], goType: basic[1], to: "%s", from: "%s"}
+	if t.String() != pt.goType {
+		pt.to = pt.goType + "(%s)"
+		pt.from = t.String() + "(%s)"
+	}
+	return pt, nil
+}
+
+func (m protoMapper) sliceConverters(prefix string, t inspect.FieldType, elem protoType) []tmplConverter {
+	loop := "if in != nil {\nout = make(%s, len(in))\nfor i, v := range in {\n%s\n}\n}\n"
+
+	return []tmplConverter{
+		{
+			Name: "encode" + prefix,
+			In:   t.String(),
+			Out:  "[]" + elem.goType,
+			Body: fmt.Sprintf(loop, "[]"+elem.goType, "out[i] = "+fmt.Sprintf(elem.to, "v")),
+		},
+		{
+			Name:  "decode" + prefix,
+			In:    "[]" + elem.goType,
+			Out:   t.String(),
+			Body:  fmt.Sprintf(loop, t.String(), decodeStmt("out[i]", elem, "v")),
+			Fails: elem.fails,
+		},
+	}
+}
+
+func (m protoMapper) mapConverters(prefix string, t inspect.FieldType, key, elem protoType) []tmplConverter {
+	loop := "if in != nil {\nout = make(%s, len(in))\nfor k, v := range in {\n%s\n}\n}\n"
+	encoded := fmt.Sprintf("map[%s]%s", key.goType, elem.goType)
+
+	return []tmplConverter{
+		{
+			Name: "encode" + prefix,
+			In:   t.String(),
+			Out:  encoded,
+			Body: fmt.Sprintf(loop, encoded, fmt.Sprintf("out[%s] = %s", fmt.Sprintf(key.to, "k"), fmt.Sprintf(elem.to, "v"))),
+		},
+		{
+			Name:  "decode" + prefix,
+			In:    encoded,
+			Out:   t.String(),
+			Body:  fmt.Sprintf(loop, t.String(), decodeStmt("out["+fmt.Sprintf(key.from, "k")+"]", elem, "v")),
+			Fails: elem.fails,
+		},
+	}
+}
+
+func (m protoMapper) pointerConverters(prefix string, t inspect.FieldType, elem protoType) []tmplConverter {
+	deref := "if in != nil {\nv := %s\nout = &v\n}\n"
+
+	return []tmplConverter{
+		{
+			Name: "encode" + prefix,
+			In:   t.String(),
+			Out:  "*" + elem.goType,
+			Body: fmt.Sprintf(deref, fmt.Sprintf(elem.to, "*in")),
+		},
+		{
+			Name: "decode" + prefix,
+			In:   "*" + elem.goType,
+			Out:  t.String(),
+			Body: fmt.Sprintf(deref, fmt.Sprintf(elem.from, "*in")),
+		},
+	}
+}
+
+func decodeStmt(out string, t protoType, in string) string {
+	if !t.fails {
+		return out + " = " + fmt.Sprintf(t.from, in)
+	}
+	return fmt.Sprintf("if %s, err = %s; err != nil {\nreturn nil, err\n}", out, fmt.Sprintf(t.from, in))
+}
+
+// goCamelCase returns the Go name protoc-gen-go gives to a proto field
+func goCamelCase(s string) string {
+	isLower := func(c byte) bool { return 'a' <= c && c <= 'z' }
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+		case isDigit(c):
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func protoFileName(systemName, tag string) string {
+	var suffix string
+	if tag != "" {
+		suffix = "_" + strings.ToLower(tag)
+	}
+	return fmt.Sprintf("%s%s.proto", systemName, suffix)
+}
+
+// protoLock persists the field numbers of a message so that they stay the
+// same as fields are added, removed or reordered. The numbers of removed
+// fields are reserved rather than reused, unless a field of the same name
+// comes back.
+type protoLock struct {
+	Message  string         `json:"message"`
+	Fields   map[string]int `json:"fields"`
+	Reserved map[string]int `json:"reserved,omitempty"`
+}
+
+func readProtoLock(path string) (protoLock, error) {
+	lock := protoLock{Fields: map[string]int{}, Reserved: map[string]int{}}
+
+	src, readErr := ioutil.ReadFile(path)
+	if os.IsNotExist(readErr) {
+		return lock, nil
+	}
+	if readErr != nil {
+		return lock, readErr
+	}
+
+	if err := json.Unmarshal(src, &lock); err != nil {
+		return lock, fmt.Errorf("%s: %s", path, err)
+	}
+	if lock.Fields == nil {
+		lock.Fields = map[string]int{}
+	}
+	if lock.Reserved == nil {
+		lock.Reserved = map[string]int{}
+	}
+	return lock, nil
+}
+
+// assign returns the numbers of the named fields, numbering the new ones
+// after the highest number ever used and reserving the removed ones
+func (l *protoLock) assign(names []string) []int {
+	next := 1
+	for _, numbers := range []map[string]int{l.Fields, l.Reserved} {
+		for _, n := range numbers {
+			if n >= next {
+				next = n + 1
+			}
+		}
+	}
+
+	current := map[string]int{}
+	numbers := make([]int, len(names))
+	for i, name := range names {
+		n, ok := l.Fields[name]
+		if !ok {
+			n, ok = l.Reserved[name]
+		}
+		if !ok {
+			n = next
+			next++
+		}
+		delete(l.Reserved, name)
+		current[name] = n
+		numbers[i] = n
+	}
+
+	for name, n := range l.Fields {
+		if _, ok := current[name]; !ok {
+			l.Reserved[name] = n
+		}
+	}
+	l.Fields = current
+
+	return numbers
+}
+
+// reserved returns the reserved numbers and quoted names in number order
+func (l protoLock) reserved() ([]string, []string) {
+	names := make([]string, 0, len(l.Reserved))
+	for name := range l.Reserved {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return l.Reserved[names[i]] < l.Reserved[names[j]] })
+
+	numbers := make([]string, len(names))
+	for i, name := range names {
+		numbers[i] = strconv.Itoa(l.Reserved[name])
+		names[i] = strconv.Quote(name)
+	}
+	return numbers, names
+}
+
+func (l protoLock) encode() ([]byte, error) {
+	src, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(src, '\n'), nil
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+const requiredTag = "required"
+
+// maxRequired is the number of bits of the mask tracking the required fields
+// set on a builder
+const maxRequired = 64
+
+// requiredFields reports which fields are required, either listed by the
+// required flag, e.g. -required ID,Name, or annotated required:"true". A
+// default sets the field on every builder, so required fields declare none.
+func requiredFields(model inspect.Names, fields []inspect.Field, names []string) ([]bool, error) {
+	known := map[string]struct{}{}
+	for _, f := range fields {
+		known[f.Names.Public] = struct{}{}
+	}
+
+	for _, name := range names {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("required references unknown field %q", name)
+		}
+	}
+
+	required := make([]bool, len(fields))
+	count := 0
+	for i, f := range fields {
+		required[i] = contains(names, f.Names.Public)
+
+		if value, ok := f.Lookup(requiredTag); ok && !required[i] {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: required expects a bool, got %q", model.Public, f.Names.Public, value)
+			}
+			required[i] = b
+		}
+
+		if !required[i] {
+			continue
+		}
+
+		if _, ok := f.Lookup(defaultTag); ok {
+			return nil, fmt.Errorf("%s.%s: required fields cannot declare a default", model.Public, f.Names.Public)
+		}
+		count++
+	}
+
+	if count > maxRequired {
+		return nil, fmt.Errorf("%s: at most %d fields can be required, got %d", model.Public, maxRequired, count)
+	}
+	return required, nil
+}
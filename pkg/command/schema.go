@@ -0,0 +1,217 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/makes-code/gen/internal/inspect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaObject is a JSON object keeping its keys in order, so that the
+// properties of a schema follow the fields of the model
+type schemaObject []schemaMember
+
+type schemaMember struct {
+	Key   string
+	Value interface{}
+}
+
+func (o schemaObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, keyErr := json.Marshal(m.Key)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+
+		value, valueErr := json.Marshal(m.Value)
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o schemaObject) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, m := range o {
+		var value yaml.Node
+		if err := value.Encode(m.Value); err != nil {
+			return nil, err
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m.Key}
+		node.Content = append(node.Content, key, &value)
+	}
+	return node, nil
+}
+
+// schemaMapper maps the payload fields to JSON Schema, which OpenAPI 3.1
+// components share. The models nested with a payload of the same tag are
+// referenced with ref, given the model, and must have a schema of the tag
+// as well.
+type schemaMapper struct {
+	encoder *modelEncoder
+	schemas *modelEncoder
+	ref     func(model inspect.TypeRef) (string, error)
+}
+
+// object returns the schema of the payload fields, keeping the JSON names
+// and options of their tags
+func (m schemaMapper) object(fields []inspect.Field) (schemaObject, error) {
+	var properties schemaObject
+	var required []string
+
+	for _, f := range fields {
+		tag := f.Tags["json"]
+		if tag.Has("-") {
+			continue
+		}
+
+		schema, err := m.schema(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", f.Names.Public, err)
+		}
+
+		if tag.Has("string") {
+			switch f.Type.Kind() {
+			case inspect.KindString, inspect.KindNumber, inspect.KindBool:
+				schema = schemaObject{{"type", "string"}}
+			}
+		}
+
+		properties = append(properties, schemaMember{f.Names.Field, schema})
+		if !tag.Has("omitempty") && f.Type.Kind() != inspect.KindNilable {
+			required = append(required, f.Names.Field)
+		}
+	}
+
+	object := schemaObject{{"type", "object"}, {"properties", properties}}
+	if len(required) > 0 {
+		object = append(object, schemaMember{"required", required})
+	}
+	return append(object, schemaMember{"additionalProperties", false}), nil
+}
+
+func (m schemaMapper) schema(t inspect.FieldType) (schemaObject, error) {
+	if ref, ok := t.Named(); ok {
+		switch {
+		case ref.PkgPath() == "time" && ref.Name == "Time":
+			return schemaObject{{"type", "string"}, {"format", "date-time"}}, nil
+		case ref.PkgPath() == "encoding/json" && ref.Name == "RawMessage":
+			return schemaObject{}, nil
+		}
+
+		// the payload stores nested models as pointers, null when nil
+		if _, ok := m.encoder.nested(t); ok {
+			if _, ok := m.schemas.nested(t); !ok {
+				return nil, fmt.Errorf("nested model %s has no schema of the same tag to reference", t)
+			}
+
+			target, err := m.ref(ref)
+			if err != nil {
+				return nil, err
+			}
+			return nullable(schemaObject{{"$ref", target}}), nil
+		}
+
+		// the types encoding themselves are described as any value, or
+		// as a string for text
+		switch {
+		case ref.HasMethod("MarshalJSON"):
+			return schemaObject{}, nil
+		case ref.HasMethod("MarshalText"):
+			return schemaObject{{"type", "string"}}, nil
+		}
+	}
+
+	if s := t.Underlying().String(); s == "[]byte" || s == "[]uint8" {
+		return schemaObject{{"type", "string"}, {"contentEncoding", "base64"}}, nil
+	}
+
+	switch t.Kind() {
+	case inspect.KindString:
+		return schemaObject{{"type", "string"}}, nil
+	case inspect.KindBool:
+		return schemaObject{{"type", "boolean"}}, nil
+	case inspect.KindNumber:
+		switch t.Basic() {
+		case "float32", "float64":
+			return schemaObject{{"type", "number"}}, nil
+		case "int32", "int16", "int8", "rune":
+			return schemaObject{{"type", "integer"}, {"format", "int32"}}, nil
+		case "uint16", "uint8", "byte":
+			return schemaObject{{"type", "integer"}, {"format", "int32"}, {"minimum", 0}}, nil
+		case "uint32":
+			return schemaObject{{"type", "integer"}, {"format", "int64"}, {"minimum", 0}}, nil
+		case "uint64", "uint", "uintptr":
+			// no format holds the values above the int64 range
+			return schemaObject{{"type", "integer"}, {"minimum", 0}}, nil
+		case "complex64", "complex128":
+			return nil, fmt.Errorf("type %s is not supported by JSON", t)
+		}
+		return schemaObject{{"type", "integer"}, {"format", "int64"}}, nil
+	}
+
+	if t.Elem() == nil {
+		if t.Kind() == inspect.KindNilable || t.Kind() == inspect.KindOther {
+			return schemaObject{}, nil
+		}
+		return nil, fmt.Errorf("type %s is not supported", t)
+	}
+
+	elem, err := m.schema(t.Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Kind() {
+	case inspect.KindSlice:
+		return schemaObject{{"type", []string{"array", "null"}}, {"items", elem}}, nil
+	case inspect.KindArray:
+		var n int
+		fmt.Sscanf(t.Underlying().String(), "[%d]", &n)
+		return schemaObject{{"type", "array"}, {"items", elem}, {"minItems", n}, {"maxItems", n}}, nil
+	case inspect.KindMap:
+		return schemaObject{{"type", []string{"object", "null"}}, {"additionalProperties", elem}}, nil
+	}
+
+	return nullable(elem), nil
+}
+
+// nullable returns the schema accepting null as well
+func nullable(schema schemaObject) schemaObject {
+	if len(schema) == 0 {
+		return schema
+	}
+
+	if len(schema) == 1 && schema[0].Key == "oneOf" {
+		alternatives := schema[0].Value.([]schemaObject)
+		if last := alternatives[len(alternatives)-1]; len(last) == 1 && last[0].Key == "type" && last[0].Value == "null" {
+			return schema
+		}
+	}
+
+	for i, m := range schema {
+		if s, ok := m.Value.(string); ok && m.Key == "type" {
+			out := append(schemaObject(nil), schema...)
+			out[i].Value = []string{s, "null"}
+			return out
+		}
+	}
+	return schemaObject{{"oneOf", []schemaObject{schema, {{"type", "null"}}}}}
+}
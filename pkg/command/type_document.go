@@ -7,19 +7,23 @@ import (
 
 	"github.com/makes-code/gen/internal/cli"
 	"github.com/makes-code/gen/internal/inspect"
-	"github.com/makes-code/gen/internal/utils"
 
 	mcli "github.com/mitchellh/cli"
 )
 
 type typeDocumentInputs struct {
-	tag     string
-	include utils.StringArray
-	exclude utils.StringArray
-	strict  bool
+	tag    string
+	fields fieldFilter
 }
 
+// documentDefaults are the default BSON names of the document fields
+var documentDefaults = map[string]string{"ID": "_id"}
+
 func TypeDocument() (mcli.Command, error) {
+	return typeDocument(), nil
+}
+
+func typeDocument() *cli.CmdCodegen {
 	var inputs typeDocumentInputs
 
 	return &cli.CmdCodegen{
@@ -30,9 +34,7 @@ func TypeDocument() (mcli.Command, error) {
 		},
 		Flags: func(fs *flag.FlagSet) {
 			fs.StringVar(&inputs.tag, "tag", "", "")
-			fs.Var(&inputs.include, "i", "")
-			fs.Var(&inputs.exclude, "x", "")
-			fs.BoolVar(&inputs.strict, "strict", false, "")
+			inputs.fields.flags(fs)
 		},
 		FileName: func(systemName string) string {
 			var suffix string
@@ -42,72 +44,89 @@ func TypeDocument() (mcli.Command, error) {
 			return fmt.Sprintf("%s_gen_document%s.go", systemName, suffix)
 		},
 		Runner: func(data inspect.Data) (string, interface{}, error) {
-			whitelist := map[string]string{}
-			for _, f := range inputs.include {
-				name := f
-				var nameOverride string
-
-				if strings.Contains(f, "=") {
-					parts := strings.Split(f, "=")
-					name, nameOverride = parts[0], parts[1]
-				}
-
-				whitelist[name] = nameOverride
+			fields, fieldsErr := inputs.fields.apply(data.Fields, "bson", documentDefaults)
+			if fieldsErr != nil {
+				return "", nil, fieldsErr
 			}
 
-			blacklist := map[string]struct{}{}
-			for _, f := range inputs.exclude {
-				blacklist[f] = struct{}{}
+			imports := data.Imports.New()
+			imports.Use("bson", `"go.mongodb.org/mongo-driver/bson"`)
+			for _, field := range fields {
+				imports.Include(field.Type.Imports()...)
 			}
+			imports.Include(data.TypeParams.Imports()...)
 
-			var fields []inspect.Field
-			for _, field := range data.Fields {
-				if _, ok := blacklist[field.Names.Public]; ok {
-					continue
-				}
-
-				nameOverride, ok := whitelist[field.Names.Public]
-				if !ok && inputs.strict {
-					continue
-				}
-
-				if field.Names.Public == "ID" {
-					field.Names.Field = "_id"
-				}
-
-				if nameOverride != "" {
-					field.Names.Field = nameOverride
-				}
+			encoded, converters, encodedErr := newModelEncoder("document", inputs.tag, data).fields(fields, data.TypeParams)
+			if encodedErr != nil {
+				return "", nil, encodedErr
+			}
 
-				fields = append(fields, field)
+			// the documents nested as pointers decode null to nil with
+			// the registry of the document
+			if len(converters) > 0 {
+				imports.Use("reflect", `"reflect"`)
+				imports.Use("bsoncodec", `"go.mongodb.org/mongo-driver/bson/bsoncodec"`)
+				imports.Use("bsonrw", `"go.mongodb.org/mongo-driver/bson/bsonrw"`)
+				imports.Use("bsontype", `"go.mongodb.org/mongo-driver/bson/bsontype"`)
 			}
 
-			imports := data.Imports.New()
-			imports.Use("bson", `"go.mongodb.org/mongo-driver/bson"`)
-			for _, field := range fields {
-				imports.Include(field.Type.Imports()...)
+			documentFields := make([]tmplDocumentField, len(encoded))
+			for i, f := range encoded {
+				documentFields[i] = tmplDocumentField{
+					tmplEncodedField: f,
+					Ordered:          isOrdered(f.Type),
+					Numeric:          f.Type.Kind() == inspect.KindNumber,
+				}
 			}
 
 			return tmplDocument, tmplDataDocument{
 				Data: inspect.Data{
-					Pkg:     data.Pkg,
-					Names:   data.Names,
-					Fields:  fields,
-					Imports: imports,
+					Pkg:        data.Pkg,
+					Names:      data.Names,
+					TypeParams: data.TypeParams,
+					Fields:     fields,
+					Imports:    imports,
 				},
-				Tag: inputs.tag,
+				Tag:        inputs.tag,
+				Fields:     documentFields,
+				Converters: converters,
+				Nested:     len(converters) > 0,
 			}, nil
 		},
-	}, nil
+	}
 }
 
 type tmplDataDocument struct {
 	inspect.Data
-	Tag string
+	Tag        string
+	Fields     []tmplDocumentField
+	Converters []tmplConverter
+	Nested     bool
+}
+
+// tmplDocumentField is a document field along with the operators its
+// filters and updates support
+type tmplDocumentField struct {
+	tmplEncodedField
+	Ordered bool
+	Numeric bool
+}
+
+// isOrdered reports whether the values of the type compare with $gt and $lt
+func isOrdered(t inspect.FieldType) bool {
+	switch t.Kind() {
+	case inspect.KindNumber, inspect.KindString:
+		return true
+	}
+	ref, ok := t.Named()
+	return ok && ref.PkgPath() == "time" && ref.Name == "Time"
 }
 
 var tmplDocument = `
 {{$ := .Names}}
+{{$tp := .TypeParams}}
+{{$ta := .TypeParams.Args}}
+{{$doc := printf "%sDocument%s" $.Public .Tag}}
 // This file is auto-generated by makes-code ... do not edit
 
 package {{.Pkg}}
@@ -119,57 +138,203 @@ import ({{range .Imports.Groups}}
 {{end}})
 {{end}}
 
-type {{$.Public}}Document{{.Tag}}s []*{{$.Public}}Document{{.Tag}}
+// The {{$doc}} field names, for use in queries
+const (
+{{range .Fields}}  {{$doc}}Field{{.Names.Public}} = {{quote .Names.Field}}
+{{end -}}
+)
 
-type {{$.Public}}Document{{.Tag}} struct {
-	{{$.Private}}Data
+type {{$.Public}}Document{{.Tag}}s{{$tp}} []*{{$.Public}}Document{{.Tag}}{{$ta}}
+
+type {{$.Public}}Document{{.Tag}}{{$tp}} struct {
+	{{$.Private}}Data{{$ta}}
 }
 
-type {{$.Private}}Document{{.Tag}} struct {
-{{range .Fields}} {{.Names.Public}} {{.Type}} {{.Tag "bson"}}
+type {{$.Private}}Document{{.Tag}}{{$tp}} struct {
+{{range .Fields}} {{.Names.Public}} {{.EncodedType}} {{.Tag "bson"}}
 {{end -}}
 }
 
-func To{{$.Public}}Document{{.Tag}}({{$.Short}} {{$.Public}}) *{{$.Public}}Document{{.Tag}} {
-	return &{{$.Public}}Document{{.Tag}}{{"{"}}{{$.Private}}Data{{"{"}}
+func To{{$.Public}}Document{{.Tag}}{{$tp}}({{$.Short}} {{$.Public}}{{$ta}}) *{{$.Public}}Document{{.Tag}}{{$ta}} {
+	return &{{$.Public}}Document{{.Tag}}{{$ta}}{{"{"}}{{$.Private}}Data{{$ta}}{{"{"}}
 {{range .Fields}}    {{.Names.Private}}: {{$.Short}}.{{.Names.Public}}(),
 {{end -}}
 	{{"}"}}{{"}"}}
 }
 
-func ({{$.Short}} {{$.Public}}Document{{.Tag}}) MarshalBSON() ([]byte, error) {
-	return bson.Marshal({{$.Private}}Document{{.Tag}}{
-{{range .Fields}}    {{.Names.Public}}: {{$.Short}}.{{.Names.Private}},
+func ({{$.Short}} {{$.Public}}Document{{.Tag}}{{$ta}}) MarshalBSON() ([]byte, error) {
+	return bson.Marshal({{$.Private}}Document{{.Tag}}{{$ta}}{
+{{range .Fields}}    {{.Names.Public}}: {{if .Encode}}{{.Encode}}({{$.Short}}.{{.Names.Private}}){{else}}{{$.Short}}.{{.Names.Private}}{{end}},
 {{end -}}
 	})
 }
 
-func ({{$.Short}} *{{$.Public}}Document{{.Tag}}) UnmarshalBSON(data []byte) error {
-	var tmp {{$.Private}}Document{{.Tag}}
-	if err := bson.Unmarshal(data, &tmp); err != nil {
+{{if .Nested}}
+// {{$.Private}}Document{{.Tag}}Registry decodes the nested documents stored as
+// null to nil, where the driver calls their UnmarshalBSON with no data
+var {{$.Private}}Document{{.Tag}}Registry = bson.NewRegistryBuilder().
+	RegisterHookDecoder(reflect.TypeOf((*bsoncodec.Unmarshaler)(nil)).Elem(), bsoncodec.ValueDecoderFunc(
+		func(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+			if vr.Type() == bsontype.Null && val.Kind() == reflect.Ptr {
+				val.Set(reflect.Zero(val.Type()))
+				return vr.ReadNull()
+			}
+			return bsoncodec.DefaultValueDecoders{}.UnmarshalerDecodeValue(dc, vr, val)
+		},
+	)).
+	Build()
+{{end}}
+func ({{$.Short}} *{{$.Public}}Document{{.Tag}}{{$ta}}) UnmarshalBSON(data []byte) error {
+	// a null document, decoded without the registry of a parent
+	if len(data) == 0 {
+		return nil
+	}
+
+	var tmp {{$.Private}}Document{{.Tag}}{{$ta}}
+	if err := {{if .Nested}}bson.UnmarshalWithRegistry({{$.Private}}Document{{.Tag}}Registry, data, &tmp){{else}}bson.Unmarshal(data, &tmp){{end}}; err != nil {
 		return err
 	}
 
-	{{$.Short}}.{{$.Private}}Data = {{$.Private}}Data{
-{{range .Fields}}    {{.Names.Private}}: tmp.{{.Names.Public}},
+	{{$.Short}}.{{$.Private}}Data = {{$.Private}}Data{{$ta}}{
+{{range .Fields}}    {{.Names.Private}}: {{if .Decode}}{{.Decode}}(tmp.{{.Names.Public}}){{else}}tmp.{{.Names.Public}}{{end}},
 {{end -}}
 	}
 	return nil
 }
 
-func To{{$.Public}}Document{{.Tag}}s({{$.Private}}s {{$.Public}}s) {{$.Public}}Document{{.Tag}}s {
-  docs := make({{$.Public}}Document{{.Tag}}s, len({{$.Private}}s))
+func To{{$.Public}}Document{{.Tag}}s{{$tp}}({{$.Private}}s {{$.Public}}s{{$ta}}) {{$.Public}}Document{{.Tag}}s{{$ta}} {
+  docs := make({{$.Public}}Document{{.Tag}}s{{$ta}}, len({{$.Private}}s))
 	for i, {{$.Private}} := range {{$.Private}}s {
 		docs[i] = To{{$.Public}}Document{{.Tag}}({{$.Private}})
 	}
 	return docs
 }
 
-func (docs {{$.Public}}Document{{.Tag}}s) {{$.Public}}s() {{$.Public}}s {
-	{{$.Private}}s := make({{$.Public}}s, len(docs))
+func (docs {{$.Public}}Document{{.Tag}}s{{$ta}}) {{$.Public}}s() {{$.Public}}s{{$ta}} {
+	{{$.Private}}s := make({{$.Public}}s{{$ta}}, len(docs))
 	for i, doc := range docs {
 		{{$.Private}}s[i] = doc
 	}
 	return {{$.Private}}s
 }
-`
+
+// {{$.Public}}{{.Tag}}Filter returns a builder of query filters on the {{$doc}} fields
+func {{$.Public}}{{.Tag}}Filter{{$tp}}() *{{$doc}}Filter{{$ta}} {
+	return &{{$doc}}Filter{{$ta}}{}
+}
+
+// {{$doc}}Filter builds a query filter matching all of its conditions
+type {{$doc}}Filter{{$tp}} struct {
+	conditions bson.D
+}
+
+func (filter *{{$doc}}Filter{{$ta}}) where(key, op string, value interface{}) *{{$doc}}Filter{{$ta}} {
+	if op != "" {
+		value = bson.D{{"{{"}}Key: op, Value: value{{"}}"}}
+	}
+	filter.conditions = append(filter.conditions, bson.E{Key: key, Value: value})
+	return filter
+}
+{{range .Fields}}
+{{- $value := "v"}}{{if .Encode}}{{$value = printf "%s(v)" .Encode}}{{end}}
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}Eq(v {{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "", {{$value}})
+}
+
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}Ne(v {{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "$ne", {{$value}})
+}
+{{if not .Encode}}
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}In(vs ...{{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "$in", vs)
+}
+
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}Nin(vs ...{{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "$nin", vs)
+}
+{{end}}{{if .Ordered}}
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}Gt(v {{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "$gt", v)
+}
+
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}Gte(v {{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "$gte", v)
+}
+
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}Lt(v {{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "$lt", v)
+}
+
+func (filter *{{$doc}}Filter{{$ta}}) {{.Names.Public}}Lte(v {{.Type}}) *{{$doc}}Filter{{$ta}} {
+	return filter.where({{$doc}}Field{{.Names.Public}}, "$lte", v)
+}
+{{end}}{{end}}
+// D returns the filter, combining the conditions with $and when several of
+// them apply to the same field
+func (filter *{{$doc}}Filter{{$ta}}) D() bson.D {
+	seen := map[string]bool{}
+	for _, c := range filter.conditions {
+		if !seen[c.Key] {
+			seen[c.Key] = true
+			continue
+		}
+
+		and := make(bson.A, len(filter.conditions))
+		for i, c := range filter.conditions {
+			and[i] = bson.D{c}
+		}
+		return bson.D{{"{{"}}Key: "$and", Value: and{{"}}"}}
+	}
+
+	if filter.conditions == nil {
+		return bson.D{}
+	}
+	return filter.conditions
+}
+
+// {{$.Public}}{{.Tag}}Update returns a builder of updates of the {{$doc}} fields
+func {{$.Public}}{{.Tag}}Update{{$tp}}() *{{$doc}}Update{{$ta}} {
+	return &{{$doc}}Update{{$ta}}{}
+}
+
+// {{$doc}}Update builds an update of $set, $unset and $inc operations
+type {{$doc}}Update{{$tp}} struct {
+	set   bson.D
+	unset bson.D
+	inc   bson.D
+}
+{{range .Fields}}
+func (update *{{$doc}}Update{{$ta}}) Set{{.Names.Public}}(v {{.Type}}) *{{$doc}}Update{{$ta}} {
+	update.set = append(update.set, bson.E{Key: {{$doc}}Field{{.Names.Public}}, Value: {{if .Encode}}{{.Encode}}(v){{else}}v{{end}}})
+	return update
+}
+
+func (update *{{$doc}}Update{{$ta}}) Unset{{.Names.Public}}() *{{$doc}}Update{{$ta}} {
+	update.unset = append(update.unset, bson.E{Key: {{$doc}}Field{{.Names.Public}}, Value: ""})
+	return update
+}
+{{if .Numeric}}
+func (update *{{$doc}}Update{{$ta}}) Inc{{.Names.Public}}(v {{.Type}}) *{{$doc}}Update{{$ta}} {
+	update.inc = append(update.inc, bson.E{Key: {{$doc}}Field{{.Names.Public}}, Value: v})
+	return update
+}
+{{end}}{{end}}
+// D returns the update document
+func (update *{{$doc}}Update{{$ta}}) D() bson.D {
+	d := bson.D{}
+	for _, op := range []bson.E{
+		{Key: "$set", Value: update.set},
+		{Key: "$unset", Value: update.unset},
+		{Key: "$inc", Value: update.inc},
+	} {
+		if len(op.Value.(bson.D)) > 0 {
+			d = append(d, op)
+		}
+	}
+	return d
+}
+{{range .Converters}}
+func {{.Name}}{{.TypeParams}}(in {{.In}}) (out {{.Out}}) {
+{{.Body}}	return out
+}
+{{end}}`
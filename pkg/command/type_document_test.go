@@ -0,0 +1,198 @@
+package command
+
+import (
+	"testing"
+)
+
+func TestDocumentBuildersNameParamsApart(t *testing.T) {
+	files := repoModule(t)
+	files["user.go"] = `package app
+
+// makes-code:model
+// makes-code:document
+type User interface {
+	Filter() string
+	Update() int
+	Identities() []string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`
+	files["user_test.go"] = `package app
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDocument(t *testing.T) {
+	filter := UserFilter().FilterIn("a", "b").IdentitiesEq([]string{"x"}).UpdateGt(1).D()
+	want := bson.D{
+		{Key: "filter", Value: bson.D{{Key: "$in", Value: []string{"a", "b"}}}},
+		{Key: "identities", Value: []string{"x"}},
+		{Key: "update", Value: bson.D{{Key: "$gt", Value: 1}}},
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("filter = %v, want %v", filter, want)
+	}
+
+	update := UserUpdate().SetFilter("b").IncUpdate(2).D()
+	want = bson.D{
+		{Key: "$set", Value: bson.D{{Key: "filter", Value: "b"}}},
+		{Key: "$inc", Value: bson.D{{Key: "update", Value: 2}}},
+	}
+	if !reflect.DeepEqual(update, want) {
+		t.Errorf("update = %v, want %v", update, want)
+	}
+
+	u := NewUserBuilder().WithFilter("a").WithUpdate(3).WithIdentities([]string{"x"}).MustBuild()
+	data, err := bson.Marshal(ToUserDocument(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc UserDocument
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&doc.userData, u) {
+		t.Errorf("unmarshaled %#v, want %#v", doc.userData, u)
+	}
+}
+`
+	writeModule(t, files)
+
+	runGen(t)
+	goTest(t)
+}
+
+func TestDocumentNilNestedModel(t *testing.T) {
+	files := repoModule(t)
+	files["user.go"] = `package app
+
+// makes-code:model
+// makes-code:document
+type User interface {
+	ID() string
+	Profile() Profile
+	Profiles() []Profile
+	ByName() map[string]Profile
+}
+
+// makes-code:model
+// makes-code:document
+type Profile interface {
+	Bio() string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`
+	files["user_test.go"] = `package app
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func roundTrip(t *testing.T, u User) *UserDocument {
+	t.Helper()
+
+	data, err := bson.Marshal(ToUserDocument(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc UserDocument
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return &doc
+}
+
+func TestNilNestedModel(t *testing.T) {
+	doc := roundTrip(t, NewUserBuilder().WithID("1").MustBuild())
+	if doc.ID() != "1" || doc.Profile() != nil || doc.Profiles() != nil || doc.ByName() != nil {
+		t.Errorf("decoded %+v", doc)
+	}
+}
+
+func TestNilNestedElements(t *testing.T) {
+	profile := NewProfileBuilder().WithBio("mathematician").MustBuild()
+	u := NewUserBuilder().WithID("1").
+		WithProfiles([]Profile{nil, profile}).
+		WithByName(map[string]Profile{"none": nil, "ada": profile}).
+		MustBuild()
+
+	doc := roundTrip(t, u)
+	if profiles := doc.Profiles(); len(profiles) != 2 || profiles[0] != nil || profiles[1].Bio() != "mathematician" {
+		t.Errorf("profiles = %v", profiles)
+	}
+	if byName := doc.ByName(); len(byName) != 2 || byName["none"] != nil || byName["ada"].Bio() != "mathematician" {
+		t.Errorf("by name = %v", byName)
+	}
+}
+`
+	writeModule(t, files)
+
+	runGen(t)
+	goTest(t)
+}
+
+func TestDocumentNamedSliceFields(t *testing.T) {
+	files := repoModule(t)
+	files["user.go"] = `package app
+
+import (
+	"net"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type Tags []string
+
+// makes-code:model
+// makes-code:document
+type User interface {
+	Addr() net.IP
+	Labels() Tags
+	Extra() bson.M
+}
+
+func prebuild(builder interface{}) error { return nil }
+`
+	files["user_test.go"] = `package app
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRoundTrip(t *testing.T) {
+	u := NewUserBuilder().WithAddr(net.IPv4(127, 0, 0, 1)).WithLabels(Tags{"admin"}).
+		WithExtra(bson.M{"plan": "pro"}).MustBuild()
+
+	data, err := bson.Marshal(ToUserDocument(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc UserDocument
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Addr().Equal(u.Addr()) || !reflect.DeepEqual(doc.Labels(), u.Labels()) || doc.Extra()["plan"] != "pro" {
+		t.Errorf("decoded %+v", doc)
+	}
+}
+`
+	writeModule(t, files)
+
+	runGen(t)
+	goTest(t)
+}
@@ -1,15 +1,40 @@
 package command
 
 import (
+	"flag"
 	"fmt"
+	"strconv"
 
 	"github.com/makes-code/gen/internal/cli"
 	"github.com/makes-code/gen/internal/inspect"
+	"github.com/makes-code/gen/internal/utils"
 
 	mcli "github.com/mitchellh/cli"
 )
 
 func TypeModel() (mcli.Command, error) {
+	return typeModel(), nil
+}
+
+type typeModelInputs struct {
+	validate bool
+	with     bool
+	clone    bool
+	equal    bool
+	required utils.StringArray
+	style    string
+}
+
+// model styles, the options style generating functional options applied to
+// the builder, which remains the type declaring the prebuild hook
+const (
+	styleBuilder = "builder"
+	styleOptions = "options"
+)
+
+func typeModel() *cli.CmdCodegen {
+	var inputs typeModelInputs
+
 	return &cli.CmdCodegen{
 		CmdMeta: cli.CmdMeta{
 			Name:     "model",
@@ -19,14 +44,126 @@ func TypeModel() (mcli.Command, error) {
 		FileName: func(systemName string) string {
 			return fmt.Sprintf("%s_gen.go", systemName)
 		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&inputs.validate, "validate", false, "")
+			fs.BoolVar(&inputs.with, "with", false, "")
+			fs.BoolVar(&inputs.clone, "clone", false, "")
+			fs.BoolVar(&inputs.equal, "equal", false, "")
+			fs.Var(&inputs.required, "required", "")
+			fs.StringVar(&inputs.style, "style", styleBuilder, "")
+		},
 		Runner: func(data inspect.Data) (string, interface{}, error) {
-			return tmplModel, data, nil
+			if inputs.style != styleBuilder && inputs.style != styleOptions {
+				return "", nil, fmt.Errorf("unknown model style %q, expected %s or %s", inputs.style, styleBuilder, styleOptions)
+			}
+
+			tmplData := tmplDataModel{
+				Data:     data,
+				Validate: inputs.validate,
+				With:     inputs.with,
+				Clone:    inputs.clone,
+				Equal:    inputs.equal,
+				Options:  inputs.style == styleOptions,
+			}
+			tmplData.Fields = make([]tmplModelField, len(data.Fields))
+			for i, f := range data.Fields {
+				tmplData.Fields[i] = tmplModelField{Field: f}
+			}
+
+			if inputs.with {
+				var names []string
+				names, tmplData.Copiers = newModelCopier(data.Names, false).funcs(data.Fields, data.TypeParams)
+				for i, name := range names {
+					tmplData.Fields[i].Copy = name
+				}
+			}
+
+			if inputs.clone {
+				var names []string
+				names, tmplData.Cloners = newModelCopier(data.Names, true).funcs(data.Fields, data.TypeParams)
+				for i, name := range names {
+					tmplData.Fields[i].Clone = name
+				}
+			}
+
+			if inputs.equal {
+				comparer := newModelComparer(data.Names)
+
+				var exprs []string
+				exprs, tmplData.Comparers = comparer.funcs(data.Fields, data.TypeParams)
+				for i, expr := range exprs {
+					tmplData.Fields[i].Equal = expr
+				}
+
+				tmplData.Imports.Use("fmt", `"fmt"`)
+				for _, i := range comparer.imports {
+					tmplData.Imports.Use(i, strconv.Quote(i))
+				}
+			}
+
+			defaults, err := fieldDefaults(data.Names, data.Fields)
+			if err != nil {
+				return "", nil, err
+			}
+			tmplData.Defaults = defaults
+
+			required, err := requiredFields(data.Names, data.Fields, splitList(inputs.required))
+			if err != nil {
+				return "", nil, err
+			}
+			for i, ok := range required {
+				if !ok {
+					continue
+				}
+				tmplData.Fields[i].Required = true
+				tmplData.Fields[i].Bit = len(tmplData.Required)
+				tmplData.Required = append(tmplData.Required, tmplData.Fields[i])
+			}
+			if len(tmplData.Required) > 0 {
+				tmplData.Imports.Use("fmt", `"fmt"`)
+				tmplData.Imports.Use("strings", `"strings"`)
+			}
+
+			if inputs.validate {
+				validations, err := fieldValidations(data.Names, data.Fields)
+				if err != nil {
+					return "", nil, err
+				}
+
+				tmplData.Imports.Use("errors", `"errors"`)
+				for _, v := range validations {
+					for _, i := range v.imports {
+						tmplData.Imports.Use(i, strconv.Quote(i))
+					}
+				}
+				tmplData.Validations = validations
+			}
+
+			return tmplModel, tmplData, nil
 		},
-	}, nil
+	}
+}
+
+type tmplDataModel struct {
+	inspect.Data
+	Fields      []tmplModelField
+	Validate    bool
+	Validations []tmplValidation
+	Defaults    []tmplDefault
+	Required    []tmplModelField
+	With        bool
+	Copiers     []tmplConverter
+	Clone       bool
+	Cloners     []tmplConverter
+	Equal       bool
+	Comparers   []tmplConverter
+	Options     bool
 }
 
 var tmplModel = `
 {{$ := .Names}}
+{{$tp := .TypeParams}}
+{{$ta := .TypeParams.Args}}
 // This file is generated by makes-code ... do not edit
 
 package {{.Pkg}}
@@ -37,42 +174,188 @@ import ({{range .Imports.Groups}}
 {{end -}}
 {{end}})
 {{end}}
+{{if .Source}}
+// {{$.Public}} is the {{$.Display}} model declared by the {{.Source}} struct
+type {{$.Public}}{{$tp}} interface {
+{{range .Fields}}  {{.Names.Public}}() {{.Type}}
+{{end}}{{if .With}}{{range .Fields}}  With{{.Names.Public}}({{.Names.Private}} {{.Type}}) {{$.Public}}{{$ta}}
+{{end}}{{end}}{{if .Clone}}  Clone() {{$.Public}}{{$ta}}
+{{end}}{{if .Equal}}  Equal(other {{$.Public}}{{$ta}}) bool
+  Diff(other {{$.Public}}{{$ta}}) []{{$.Public}}FieldChange
+{{end}}  Builder() *{{$.Public}}Builder{{$ta}}
+}
+{{end}}
+type {{$.Public}}s{{$tp}} []{{$.Public}}{{$ta}}
 
-type {{$.Public}}s []{{$.Public}}
-
-type {{$.Private}}Data struct {
+type {{$.Private}}Data{{$tp}} struct {
 {{range .Fields}}  {{.Names.Private}} {{.Type}}
 {{end -}}
 }
 {{range .Fields}}
-func ({{$.Short}} *{{$.Private}}Data) {{.Names.Public}}() {{.Type}} { return {{$.Short}}.{{.Names.Private}} }
+func ({{$.Short}} *{{$.Private}}Data{{$ta}}) {{.Names.Public}}() {{.Type}} { return {{$.Short}}.{{.Names.Private}} }
 {{- end}}
-func ({{$.Short}} *{{$.Private}}Data) Builder() *{{$.Public}}Builder {
-  return New{{$.Public}}Builder(){{range .Fields}}.
+func ({{$.Short}} *{{$.Private}}Data{{$ta}}) Builder() *{{$.Public}}Builder{{$ta}} {
+  return New{{$.Public}}Builder{{$ta}}(){{range .Fields}}.
     With{{.Names.Public}}({{$.Short}}.{{.Names.Private}}){{end}}
 }
+{{if .With}}
+// copy returns a copy of the {{$.Display}} sharing none of its slices and maps
+func ({{$.Short}} *{{$.Private}}Data{{$ta}}) copy() *{{$.Private}}Data{{$ta}} {
+  c := *{{$.Short}}
+{{- range .Fields}}{{if .Copy}}
+  c.{{.Names.Private}} = {{.Copy}}({{$.Short}}.{{.Names.Private}})
+{{- end}}{{end}}
+  return &c
+}
+{{range .Fields}}
+// With{{.Names.Public}} returns a copy of the {{$.Display}} with the {{.Names.Display}}
+func ({{$.Short}} *{{$.Private}}Data{{$ta}}) With{{.Names.Public}}({{.Names.Private}} {{.Type}}) {{$.Public}}{{$ta}} {
+  c := {{$.Short}}.copy()
+  c.{{.Names.Private}} = {{if .Copy}}{{.Copy}}({{.Names.Private}}){{else}}{{.Names.Private}}{{end}}
+  return c
+}
+{{end}}{{range .Copiers}}
+func {{.Name}}{{.TypeParams}}(in {{.In}}) (out {{.Out}}) {
+{{.Body}}	return out
+}
+{{end}}{{end}}
+{{- if .Clone}}
+// Clone returns a deep copy of the {{$.Display}}
+func ({{$.Short}} *{{$.Private}}Data{{$ta}}) Clone() {{$.Public}}{{$ta}} {
+  c := *{{$.Short}}
+{{- range .Fields}}{{if .Clone}}
+  c.{{.Names.Private}} = {{.Clone}}({{$.Short}}.{{.Names.Private}})
+{{- end}}{{end}}
+  return &c
+}
+{{range .Cloners}}
+func {{.Name}}{{.TypeParams}}(in {{.In}}) (out {{.Out}}) {
+{{.Body}}	return out
+}
+{{end}}{{end}}
+{{- if .Equal}}
+// Equal reports whether the other {{$.Display}} holds equal values, nil and
+// empty slices and maps being equal
+func ({{$.Short}} *{{$.Private}}Data{{$ta}}) Equal(other {{$.Public}}{{$ta}}) bool {
+  if other == nil {
+    return false
+  }
+  return {{if not .Fields}}true{{end}}{{range $i, $f := .Fields}}{{if $i}} &&
+    {{end}}{{printf $f.Equal (print $.Short "." $f.Names.Private) (print "other." $f.Names.Public "()")}}{{end}}
+}
+
+// {{$.Public}}FieldChange is a {{$.Display}} field holding different values in
+// two {{$.Display}} models, as returned by Diff
+type {{$.Public}}FieldChange struct {
+  Field string
+  From  interface{}
+  To    interface{}
+}
+
+func (c {{$.Public}}FieldChange) String() string {
+  return fmt.Sprintf("%s: %v -> %v", c.Field, c.From, c.To)
+}
+
+// Diff returns the fields holding different values in the other {{$.Display}},
+// which a nil {{$.Display}} holds the zero values of
+func ({{$.Short}} *{{$.Private}}Data{{$ta}}) Diff(other {{$.Public}}{{$ta}}) []{{$.Public}}FieldChange {
+  if other == nil {
+    other = &{{$.Private}}Data{{$ta}}{}
+  }
+
+  var changes []{{$.Public}}FieldChange
+{{- range .Fields}}
+  if !({{printf .Equal (print $.Short "." .Names.Private) (print "other." .Names.Public "()")}}) {
+    changes = append(changes, {{$.Public}}FieldChange{Field: {{quote .Names.Public}}, From: {{$.Short}}.{{.Names.Private}}, To: other.{{.Names.Public}}()})
+  }
+{{- end}}
+  return changes
+}
+{{range .Comparers}}
+func {{.Name}}{{.TypeParams}}(a, b {{.In}}) bool {
+{{.Body}}}
+{{end}}{{end}}
 
 // {{$.Public}}Builder is a {{$.Display}} builder
-type {{$.Public}}Builder struct {
-  data {{$.Private}}Data
+type {{$.Public}}Builder{{$tp}} struct {
+  data {{$.Private}}Data{{$ta}}
+{{- if .Required}}
+  set  uint64
+{{- end}}
 }
 
 // New{{$.Public}}Builder returns a new {{$.Display}} builder
-func New{{$.Public}}Builder() *{{$.Public}}Builder {
-  return &{{$.Public}}Builder{}
+func New{{$.Public}}Builder{{$tp}}() *{{$.Public}}Builder{{$ta}} {
+{{- if .Defaults}}
+  return &{{$.Public}}Builder{{$ta}}{data: {{$.Private}}Data{{$ta}}{
+{{- range .Defaults}}
+    {{.Field.Names.Private}}: {{.Value}},
+{{- end}}
+  }}
+{{- else}}
+  return &{{$.Public}}Builder{{$ta}}{}
+{{- end}}
 }
 {{range .Fields}}
 // With{{.Names.Public}} sets the {{$.Display}} {{.Names.Display}}
-func (builder *{{$.Public}}Builder) With{{.Names.Public}}({{.Names.Private}} {{.Type}}) *{{$.Public}}Builder {
+func (builder *{{$.Public}}Builder{{$ta}}) With{{.Names.Public}}({{.Names.Private}} {{.Type}}) *{{$.Public}}Builder{{$ta}} {
   builder.data.{{.Names.Private}} = {{.Names.Private}}
+{{- if .Required}}
+  builder.set |= 1 << {{.Bit}}
+{{- end}}
   return builder
 }
 {{end}}
 // Data returns the {{$.Display}} data
-func (builder *{{$.Public}}Builder) Data() {{$.Public}} { return &builder.data }
+func (builder *{{$.Public}}Builder{{$ta}}) Data() {{$.Public}}{{$ta}} { return &builder.data }
+{{if .Required}}
+// {{$.Public}}MissingFieldsError lists the required {{$.Display}} fields its
+// builder was never given
+type {{$.Public}}MissingFieldsError struct {
+  Fields []string
+}
+
+func (e *{{$.Public}}MissingFieldsError) Error() string {
+  return fmt.Sprintf("{{$.Display}} is missing required fields: %s", strings.Join(e.Fields, ", "))
+}
 
-// Build validates and returns the built {{$.Display}}
-func (builder *{{$.Public}}Builder) Build() ({{$.Public}}, error) {
+// missing returns the required {{$.Display}} fields the builder was never given
+func (builder *{{$.Public}}Builder{{$ta}}) missing() []string {
+  var fields []string
+{{- range .Required}}
+  if builder.set&(1<<{{.Bit}}) == 0 {
+    fields = append(fields, {{quote .Names.Public}})
+  }
+{{- end}}
+  return fields
+}
+{{end}}
+{{- if .Validate}}
+// Validate checks the {{$.Display}} fields against their validation rules,
+// returning every failed rule
+func (builder *{{$.Public}}Builder{{$ta}}) Validate() error {
+  var errs []error
+{{- range .Validations}}
+  if {{.Check}} {
+    errs = append(errs, errors.New({{quote .Message}}))
+  }
+{{- end}}
+  return errors.Join(errs...)
+}
+{{end}}
+// Build validates and returns the built {{$.Display}}{{if .Required}}, failing with a
+// *{{$.Public}}MissingFieldsError when required fields were never set{{end}}
+func (builder *{{$.Public}}Builder{{$ta}}) Build() ({{$.Public}}{{$ta}}, error) {
+{{- if .Required}}
+  if missing := builder.missing(); len(missing) > 0 {
+    return nil, &{{$.Public}}MissingFieldsError{Fields: missing}
+  }
+{{- end}}
+{{- if .Validate}}
+  if err := builder.Validate(); err != nil {
+    return nil, err
+  }
+{{- end}}
   if err := prebuild(builder); err != nil {
     return nil, err
   }
@@ -80,11 +363,30 @@ func (builder *{{$.Public}}Builder) Build() ({{$.Public}}, error) {
 }
 
 // MustBuild returns the built {{$.Display}} and panics if any validation error occurs
-func (builder *{{$.Public}}Builder) MustBuild() {{$.Public}} {
+func (builder *{{$.Public}}Builder{{$ta}}) MustBuild() {{$.Public}}{{$ta}} {
   built, err := builder.Build()
   if err != nil {
     panic("failed to build {{$.Display}}: " + err.Error())
   }
   return built
 }
+{{- if .Options}}
+
+// {{$.Public}}Option sets a field of a new {{$.Display}}
+type {{$.Public}}Option{{$tp}} func(*{{$.Public}}Builder{{$ta}})
+{{range .Fields}}
+// With{{$.Public}}{{.Names.Public}} sets the {{$.Display}} {{.Names.Display}}
+func With{{$.Public}}{{.Names.Public}}{{$tp}}({{.Names.Private}} {{.Type}}) {{$.Public}}Option{{$ta}} {
+  return func(builder *{{$.Public}}Builder{{$ta}}) { builder.With{{.Names.Public}}({{.Names.Private}}) }
+}
+{{end}}
+// New{{$.Public}} returns the {{$.Display}} built from the options
+func New{{$.Public}}{{$tp}}(opts ...{{$.Public}}Option{{$ta}}) ({{$.Public}}{{$ta}}, error) {
+  builder := New{{$.Public}}Builder{{$ta}}()
+  for _, opt := range opts {
+    opt(builder)
+  }
+  return builder.Build()
+}
+{{- end}}
 `
@@ -0,0 +1,327 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// goVet type-checks the module in the working directory
+func goVet(t *testing.T) {
+	t.Helper()
+
+	out, err := exec.Command("go", "vet", "./...").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go vet: %s\n%s", err, out)
+	}
+}
+
+// goTest runs the tests of the module in the working directory
+func goTest(t *testing.T) {
+	t.Helper()
+
+	out, err := exec.Command("go", "test", "./...").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go test: %s\n%s", err, out)
+	}
+}
+
+func TestModelFromStructSourceCompiles(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:model name=User with clone
+type userSpec struct {
+	id   string
+	name string
+	tags []string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+		"use.go": `package app
+
+func use() {
+	u := NewUserBuilder().WithID("1").WithName("Ada").MustBuild()
+	u = u.WithTags([]string{"admin"})
+	_, _, _ = u.ID(), u.Name(), u.Tags()
+	_ = u.Clone()
+}
+`,
+	})
+
+	runGen(t)
+	goVet(t)
+}
+
+func TestModelDiff(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:model equal
+type User interface {
+	Name() string
+	Tags() []string
+
+	Equal(other User) bool
+	Diff(other User) []UserFieldChange
+	Builder() *UserBuilder
+}
+
+// makes-code:model equal
+type Team interface {
+	Name() string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+		"user_test.go": `package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := NewUserBuilder().WithName("Ada").WithTags([]string{"admin"}).MustBuild()
+	b := NewUserBuilder().WithName("Ada").MustBuild()
+
+	if !a.Equal(a.Builder().MustBuild()) {
+		t.Error("a copy of the user is not equal")
+	}
+
+	want := []UserFieldChange{{Field: "Tags", From: []string{"admin"}, To: []string(nil)}}
+	if got := a.Diff(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %v, want %v", got, want)
+	}
+	if got := want[0].String(); got != "Tags: [admin] -> []" {
+		t.Errorf("String = %q", got)
+	}
+	if got := a.Diff(nil); len(got) != 2 {
+		t.Errorf("Diff(nil) = %v, want both fields", got)
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+}
+
+func TestModelRequiredFields(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:model required=ID,Email style=options
+type User interface {
+	ID() string
+	Email() string
+	// default: "guest"
+	Role() string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+		"user_test.go": `package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	_, err := NewUser(WithUserEmail("ada@example.com"))
+
+	var missing *UserMissingFieldsError
+	if !errors.As(err, &missing) || !reflect.DeepEqual(missing.Fields, []string{"ID"}) {
+		t.Fatalf("err = %v, want ID missing", err)
+	}
+	if err.Error() != "user is missing required fields: ID" {
+		t.Errorf("err = %q", err)
+	}
+
+	u, err := NewUser(WithUserID(""), WithUserEmail("ada@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Role() != "guest" {
+		t.Errorf("role = %q, want the default", u.Role())
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+}
+
+// namedSliceModel declares the source of a model whose fields are of named
+// slice and map types, generated with the given marker args
+const namedSliceModel = `package app
+
+import "net"
+
+type Tags []string
+
+type Scores map[string][]int
+
+// makes-code:model name=User %s
+type userSpec struct {
+	addr   net.IP
+	labels Tags
+	scores Scores
+}
+
+func prebuild(builder interface{}) error { return nil }
+`
+
+func TestModelWithCopiesNamedSlices(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": fmt.Sprintf(namedSliceModel, "with"),
+		"user_test.go": `package app
+
+import (
+	"net"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	labels := Tags{"admin"}
+	addr := net.IPv4(127, 0, 0, 1)
+	scores := Scores{"go": {1}}
+
+	u := NewUserBuilder().MustBuild().WithLabels(labels).WithAddr(addr).WithScores(scores)
+	labels[0], addr[15], scores["go"][0] = "guest", 2, 2
+
+	if u.Labels()[0] != "admin" || !u.Addr().Equal(net.IPv4(127, 0, 0, 1)) || u.Scores()["go"][0] != 1 {
+		t.Errorf("the user shares the given values: %v %v %v", u.Labels(), u.Addr(), u.Scores())
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+}
+
+func TestModelCloneAndEqualNamedSlices(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": fmt.Sprintf(namedSliceModel, "clone equal"),
+		"user_test.go": `package app
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCloneAndEqual(t *testing.T) {
+	u := NewUserBuilder().WithAddr(net.IPv4(127, 0, 0, 1)).WithLabels(Tags{"admin"}).
+		WithScores(Scores{"go": {1}}).MustBuild()
+
+	c := u.Clone()
+	if !c.Equal(u) {
+		t.Fatalf("the clone %v differs: %v", c, u.Diff(c))
+	}
+
+	c.Labels()[0], c.Addr()[15], c.Scores()["go"][0] = "guest", 2, 2
+	if u.Labels()[0] != "admin" || !u.Addr().Equal(net.IPv4(127, 0, 0, 1)) || u.Scores()["go"][0] != 1 {
+		t.Errorf("the clone shares the values of the user: %v %v %v", u.Labels(), u.Addr(), u.Scores())
+	}
+
+	if c.Equal(u) {
+		t.Error("the changed clone equals the user")
+	}
+	if diff := u.Diff(c); len(diff) != 3 {
+		t.Errorf("Diff = %v, want the 3 fields", diff)
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+}
+
+func TestModelOptionsStyle(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.go": `package app
+
+import "errors"
+
+// makes-code:model style=options
+type User interface {
+	ID() string
+	Name() string
+	Tags() []string
+}
+
+// makes-code:model
+type Team interface {
+	Name() string
+}
+
+var prebuilt []interface{}
+
+func prebuild(builder interface{}) error {
+	prebuilt = append(prebuilt, builder)
+	if b, ok := builder.(*UserBuilder); ok && b.Data().Name() == "" {
+		return errors.New("a user needs a name")
+	}
+	return nil
+}
+`,
+		"user_test.go": `package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	prebuilt = nil
+	u, err := NewUser(WithUserName("x"), WithUserID("1"), WithUserName("Ada"), WithUserTags([]string{"admin"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID() != "1" || u.Name() != "Ada" || !reflect.DeepEqual(u.Tags(), []string{"admin"}) {
+		t.Errorf("NewUser = %s %s %v", u.ID(), u.Name(), u.Tags())
+	}
+	if len(prebuilt) == 0 {
+		t.Error("NewUser does not go through prebuild")
+	}
+
+	if _, err := NewUser(WithUserID("1")); err == nil || err.Error() != "a user needs a name" {
+		t.Errorf("NewUser without a name returned %v", err)
+	}
+
+	opts := []UserOption{WithUserName("Ada")}
+	b, err := NewUser(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID() != "" || b.Tags() != nil {
+		t.Errorf("NewUser sets fields without options: %q %v", b.ID(), b.Tags())
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+
+	team, err := os.ReadFile(filepath.Join(dir, "team_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(team), "TeamOption") {
+		t.Errorf("the builder style generates options:\n%s", team)
+	}
+
+	if err := generate(t, typeModel(), "User", "-style", "fluent"); err == nil || !strings.Contains(err.Error(), `unknown model style "fluent"`) {
+		t.Errorf("generating an unknown style returned %v", err)
+	}
+}
@@ -3,24 +3,33 @@ package command
 import (
 	"flag"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/makes-code/gen/internal/cli"
 	"github.com/makes-code/gen/internal/inspect"
-	"github.com/makes-code/gen/internal/utils"
 
 	mcli "github.com/mitchellh/cli"
 )
 
 type typePayloadInputs struct {
-	tag     string
-	include utils.StringArray
-	exclude utils.StringArray
-	strict  bool
+	tag    string
+	tsOut  string
+	fields fieldFilter
 }
 
 func TypePayload() (mcli.Command, error) {
-	var inputs typePayloadInputs
+	return typePayload(), nil
+}
+
+func typePayload() *cli.CmdCodegen {
+	return newTypePayload(&typePayloadInputs{})
+}
+
+// newTypePayload returns the payload generator setting the inputs, which
+// the schema reads the fields of the payload from
+func newTypePayload(inputs *typePayloadInputs) *cli.CmdCodegen {
+	var files []cli.File
 
 	return &cli.CmdCodegen{
 		CmdMeta: cli.CmdMeta{
@@ -37,44 +46,16 @@ func TypePayload() (mcli.Command, error) {
 		},
 		Flags: func(fs *flag.FlagSet) {
 			fs.StringVar(&inputs.tag, "tag", "", "")
-			fs.Var(&inputs.include, "i", "")
-			fs.Var(&inputs.exclude, "x", "")
-			fs.BoolVar(&inputs.strict, "strict", false, "")
+			fs.StringVar(&inputs.tsOut, "ts-out", "", "")
+			inputs.fields.flags(fs)
+		},
+		Files: func() []cli.File {
+			return files
 		},
 		Runner: func(data inspect.Data) (string, interface{}, error) {
-			whitelist := map[string]string{}
-			for _, f := range inputs.include {
-				name := f
-				var nameOverride string
-
-				if strings.Contains(f, "=") {
-					parts := strings.Split(f, "=")
-					name, nameOverride = parts[0], parts[1]
-				}
-
-				whitelist[name] = nameOverride
-			}
-
-			blacklist := map[string]struct{}{}
-			for _, f := range inputs.exclude {
-				blacklist[f] = struct{}{}
-			}
-
-			var fields []inspect.Field
-			for _, field := range data.Fields {
-				if _, ok := blacklist[field.Names.Public]; ok {
-					continue
-				}
-
-				nameOverride, ok := whitelist[field.Names.Public]
-				if !ok && inputs.strict {
-					continue
-				}
-				if nameOverride != "" {
-					field.Names.Field = nameOverride
-				}
-
-				fields = append(fields, field)
+			fields, fieldsErr := inputs.fields.apply(data.Fields, "json", nil)
+			if fieldsErr != nil {
+				return "", nil, fieldsErr
 			}
 
 			imports := data.Imports.New()
@@ -82,27 +63,65 @@ func TypePayload() (mcli.Command, error) {
 			for _, field := range fields {
 				imports.Include(field.Type.Imports()...)
 			}
+			imports.Include(data.TypeParams.Imports()...)
 
-			return tmplPayload, tmplDataPayload{
+			encoder := newModelEncoder("payload", inputs.tag, data)
+			encoded, converters, encodedErr := encoder.fields(fields, data.TypeParams)
+			if encodedErr != nil {
+				return "", nil, encodedErr
+			}
+
+			tmplData := tmplDataPayload{
 				Data: inspect.Data{
-					Pkg:     data.Pkg,
-					Names:   data.Names,
-					Fields:  fields,
-					Imports: imports,
+					Pkg:        data.Pkg,
+					Names:      data.Names,
+					TypeParams: data.TypeParams,
+					Fields:     fields,
+					Imports:    imports,
 				},
-				Tag: inputs.tag,
-			}, nil
+				Tag:        inputs.tag,
+				Fields:     encoded,
+				Converters: converters,
+			}
+
+			if inputs.tsOut != "" {
+				ts := tsMapper{encoder: encoder, params: data.TypeParams}
+				tsFields, tsErr := ts.fields(fields)
+				if tsErr != nil {
+					return "", nil, fmt.Errorf("%s.%s", data.Names.Public, tsErr)
+				}
+				if encoder.err != nil {
+					return "", nil, encoder.err
+				}
+				tmplData.TSFields, tmplData.TSImports = tsFields, ts.imports
+				tmplData.TSGenerics = ts.generics()
+
+				files = []cli.File{{
+					Name:     "payload-ts",
+					Path:     filepath.Join(inputs.tsOut, tsFileName(data.Names.System, inputs.tag)+".ts"),
+					Template: tmplPayloadTS,
+				}}
+			}
+
+			return tmplPayload, tmplData, nil
 		},
-	}, nil
+	}
 }
 
 type tmplDataPayload struct {
 	inspect.Data
-	Tag string
+	Tag        string
+	Fields     []tmplEncodedField
+	Converters []tmplConverter
+	TSFields   []tmplTSField
+	TSImports  []tmplTSImport
+	TSGenerics string
 }
 
 var tmplPayload = `
 {{$ := .Names}}
+{{$tp := .TypeParams}}
+{{$ta := .TypeParams.Args}}
 // This file is auto-generated by makes-code ... do not edit
 
 package {{.Pkg}}
@@ -114,57 +133,61 @@ import ({{range .Imports.Groups}}
 {{end}})
 {{end}}
 
-type {{$.Public}}Payload{{.Tag}}s []*{{$.Public}}Payload{{.Tag}}
+type {{$.Public}}Payload{{.Tag}}s{{$tp}} []*{{$.Public}}Payload{{.Tag}}{{$ta}}
 
-type {{$.Public}}Payload{{.Tag}} struct {
-	{{$.Private}}Data
+type {{$.Public}}Payload{{.Tag}}{{$tp}} struct {
+	{{$.Private}}Data{{$ta}}
 }
 
-type {{$.Private}}Payload{{.Tag}} struct {
-{{range .Fields}} {{.Names.Public}} {{.Type}} {{.Tag "json"}}
+type {{$.Private}}Payload{{.Tag}}{{$tp}} struct {
+{{range .Fields}} {{.Names.Public}} {{.EncodedType}} {{.Tag "json"}}
 {{end -}}
 }
 
-func To{{$.Public}}Payload{{.Tag}}({{$.Short}} {{$.Public}}) *{{$.Public}}Payload{{.Tag}} {
-	return &{{$.Public}}Payload{{.Tag}}{{"{"}}{{$.Private}}Data{{"{"}}
+func To{{$.Public}}Payload{{.Tag}}{{$tp}}({{$.Short}} {{$.Public}}{{$ta}}) *{{$.Public}}Payload{{.Tag}}{{$ta}} {
+	return &{{$.Public}}Payload{{.Tag}}{{$ta}}{{"{"}}{{$.Private}}Data{{$ta}}{{"{"}}
 {{range .Fields}}    {{.Names.Private}}: {{$.Short}}.{{.Names.Public}}(),
 {{end -}}
 	{{"}"}}{{"}"}}
 }
 
-func ({{$.Short}} {{$.Public}}Payload{{.Tag}}) MarshalJSON() ([]byte, error) {
-	return json.Marshal({{$.Private}}Payload{{.Tag}}{
-{{range .Fields}}    {{.Names.Public}}: {{$.Short}}.{{.Names.Private}},
+func ({{$.Short}} {{$.Public}}Payload{{.Tag}}{{$ta}}) MarshalJSON() ([]byte, error) {
+	return json.Marshal({{$.Private}}Payload{{.Tag}}{{$ta}}{
+{{range .Fields}}    {{.Names.Public}}: {{if .Encode}}{{.Encode}}({{$.Short}}.{{.Names.Private}}){{else}}{{$.Short}}.{{.Names.Private}}{{end}},
 {{end -}}
 	})
 }
 
-func ({{$.Short}} *{{$.Public}}Payload{{.Tag}}) UnmarshalJSON(data []byte) error {
-	var tmp {{$.Private}}Payload{{.Tag}}
+func ({{$.Short}} *{{$.Public}}Payload{{.Tag}}{{$ta}}) UnmarshalJSON(data []byte) error {
+	var tmp {{$.Private}}Payload{{.Tag}}{{$ta}}
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
 
-	{{$.Short}}.{{$.Private}}Data = {{$.Private}}Data{
-{{range .Fields}}    {{.Names.Private}}: tmp.{{.Names.Public}},
+	{{$.Short}}.{{$.Private}}Data = {{$.Private}}Data{{$ta}}{
+{{range .Fields}}    {{.Names.Private}}: {{if .Decode}}{{.Decode}}(tmp.{{.Names.Public}}){{else}}tmp.{{.Names.Public}}{{end}},
 {{end -}}
 	}
 	return nil
 }
 
-func To{{$.Public}}Payload{{.Tag}}s({{$.Private}}s {{$.Public}}s) {{$.Public}}Payload{{.Tag}}s {
-  docs := make({{$.Public}}Payload{{.Tag}}s, len({{$.Private}}s))
+func To{{$.Public}}Payload{{.Tag}}s{{$tp}}({{$.Private}}s {{$.Public}}s{{$ta}}) {{$.Public}}Payload{{.Tag}}s{{$ta}} {
+  docs := make({{$.Public}}Payload{{.Tag}}s{{$ta}}, len({{$.Private}}s))
 	for i, {{$.Private}} := range {{$.Private}}s {
 		docs[i] = To{{$.Public}}Payload{{.Tag}}({{$.Private}})
 	}
 	return docs
 }
 
-func (docs {{$.Public}}Payload{{.Tag}}s) {{$.Public}}s() {{$.Public}}s {
-	{{$.Private}}s := make({{$.Public}}s, len(docs))
+func (docs {{$.Public}}Payload{{.Tag}}s{{$ta}}) {{$.Public}}s() {{$.Public}}s{{$ta}} {
+	{{$.Private}}s := make({{$.Public}}s{{$ta}}, len(docs))
 	for i, doc := range docs {
 		{{$.Private}}s[i] = doc
 	}
 	return {{$.Private}}s
 }
-`
+{{range .Converters}}
+func {{.Name}}{{.TypeParams}}(in {{.In}}) (out {{.Out}}) {
+{{.Body}}	return out
+}
+{{end}}`
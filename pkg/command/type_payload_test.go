@@ -0,0 +1,229 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPayloadRoundTripsJSON(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+import "time"
+
+// makes-code:model
+// makes-code:payload
+// makes-code:payload tag=Public strict include=Name=name
+type User interface {
+	Name() string
+	Age() int
+	Joined() time.Time
+	Tags() []string
+	Profile() Profile
+}
+
+// makes-code:model
+// makes-code:payload
+type Profile interface {
+	Bio() string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+		"user_test.go": `package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	joined := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	profile := NewProfileBuilder().WithBio("mathematician").MustBuild()
+	u := NewUserBuilder().WithName("Ada").WithAge(36).WithJoined(joined).
+		WithTags([]string{"admin"}).WithProfile(profile).MustBuild()
+
+	src, err := json.Marshal(ToUserPayload(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload UserPayload
+	if err := json.Unmarshal(src, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Name() != "Ada" || payload.Age() != 36 || !payload.Joined().Equal(joined) {
+		t.Errorf("decoded %s as %+v", src, payload)
+	}
+	if len(payload.Tags()) != 1 || payload.Tags()[0] != "admin" {
+		t.Errorf("tags = %v", payload.Tags())
+	}
+	if payload.Profile() == nil || payload.Profile().Bio() != "mathematician" {
+		t.Errorf("profile = %v", payload.Profile())
+	}
+
+	public, err := json.Marshal(ToUserPayloadPublic(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(public) != ` + "`" + `{"name":"Ada"}` + "`" + ` {
+		t.Errorf("public payload = %s", public)
+	}
+}
+
+func TestNilNestedModel(t *testing.T) {
+	u := NewUserBuilder().WithName("Ada").MustBuild()
+
+	src, err := json.Marshal(ToUserPayload(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload UserPayload
+	if err := json.Unmarshal(src, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Profile() != nil {
+		t.Errorf("decoded %s with profile %v", src, payload.Profile())
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+}
+
+func TestPayloadTypeScript(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.go": `package app
+
+import "time"
+
+// makes-code:payload ts-out=web
+type User interface {
+	Name() string
+	Age() uint8
+	Joined() time.Time
+	Tags() []string
+	Profiles() []Profile
+}
+
+// makes-code:payload ts-out=web
+type Profile interface {
+	Bio() string
+}
+`,
+	})
+
+	runGen(t)
+
+	src, err := os.ReadFile(filepath.Join(dir, "web", "user_payload.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// This file is auto-generated by makes-code ... do not edit
+
+import type { ProfilePayload } from "./profile_payload";
+
+export interface UserPayload {
+  name: string;
+  age: number;
+  joined: string;
+  tags: string[] | null;
+  profiles: (ProfilePayload | null)[] | null;
+}
+`
+	if string(src) != want {
+		t.Errorf("user_payload.ts =\n%s\nwant\n%s", src, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "web", "profile_payload.ts")); err != nil {
+		t.Errorf("the imported interface is not written: %s", err)
+	}
+}
+
+func TestPayloadNamedSliceFields(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.go": `package app
+
+import (
+	"encoding/json"
+	"net"
+)
+
+type Tags []string
+
+type Team []Profile
+
+// makes-code:model
+// makes-code:payload ts-out=web
+type User interface {
+	Addr() net.IP
+	Labels() Tags
+	Raw() json.RawMessage
+	Team() Team
+}
+
+// makes-code:model
+// makes-code:payload ts-out=web
+type Profile interface {
+	Bio() string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+		"user_test.go": `package app
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	profile := NewProfileBuilder().WithBio("mathematician").MustBuild()
+	u := NewUserBuilder().WithAddr(net.IPv4(127, 0, 0, 1)).WithLabels(Tags{"admin"}).
+		WithRaw(json.RawMessage("{}")).WithTeam(Team{profile, nil}).MustBuild()
+
+	src, err := json.Marshal(ToUserPayload(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload UserPayload
+	if err := json.Unmarshal(src, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if !payload.Addr().Equal(u.Addr()) || !reflect.DeepEqual(payload.Labels(), u.Labels()) || string(payload.Raw()) != "{}" {
+		t.Errorf("decoded %s as %+v", src, payload)
+	}
+	if team := payload.Team(); len(team) != 2 || team[0].Bio() != "mathematician" || team[1] != nil {
+		t.Errorf("team = %v", team)
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+
+	src, err := os.ReadFile(filepath.Join(dir, "web", "user_payload.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"addr: string;",
+		"labels: string[] | null;",
+		"raw: unknown;",
+		"team: (ProfilePayload | null)[] | null;",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("user_payload.ts does not declare %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/makes-code/gen/internal/cli"
+	"github.com/makes-code/gen/internal/inspect"
+
+	mcli "github.com/mitchellh/cli"
+)
+
+type typeProtoInputs struct {
+	tag          string
+	message      string
+	goPackage    string
+	protoPackage string
+	protoDir     string
+	fields       fieldFilter
+}
+
+func TypeProto() (mcli.Command, error) {
+	return typeProto(), nil
+}
+
+func typeProto() *cli.CmdCodegen {
+	var inputs typeProtoInputs
+	var files []cli.File
+
+	return &cli.CmdCodegen{
+		CmdMeta: cli.CmdMeta{
+			Name:     "proto",
+			Help:     "Generate a protobuf message and the converters from and to the protoc generated struct",
+			Synopsis: "Generate a protobuf message",
+		},
+		FileName: func(systemName string) string {
+			var suffix string
+			if inputs.tag != "" {
+				suffix = "_" + strings.ToLower(inputs.tag)
+			}
+			return fmt.Sprintf("%s_gen_proto%s.go", systemName, suffix)
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&inputs.tag, "tag", "", "")
+			fs.StringVar(&inputs.message, "message", "", "")
+			fs.StringVar(&inputs.goPackage, "go-package", "", "")
+			fs.StringVar(&inputs.protoPackage, "proto-package", "", "")
+			fs.StringVar(&inputs.protoDir, "proto-dir", ".", "")
+			inputs.fields.flags(fs)
+		},
+		Files: func() []cli.File {
+			return files
+		},
+		Runner: func(data inspect.Data) (string, interface{}, error) {
+			if len(data.TypeParams) > 0 {
+				return "", nil, fmt.Errorf("%s: proto messages cannot be generic", data.Names.Public)
+			}
+
+			if inputs.goPackage == "" {
+				return "", nil, fmt.Errorf("%s: -go-package is required to reference the protoc generated code", data.Names.Public)
+			}
+
+			fields, fieldsErr := inputs.fields.apply(data.Fields, "proto", nil)
+			if fieldsErr != nil {
+				return "", nil, fieldsErr
+			}
+
+			goPath, alias := protoGoPackage(inputs.goPackage)
+
+			imports := data.Imports.New()
+			imports.Use(alias, fmt.Sprintf("%s %q", alias, goPath))
+			for _, field := range fields {
+				imports.Include(field.Type.Imports()...)
+			}
+
+			message := inputs.message
+			if message == "" {
+				message = data.Names.Public + inputs.tag
+			}
+
+			protoPackage := inputs.protoPackage
+			if protoPackage == "" {
+				protoPackage = data.Pkg
+			}
+
+			schema := filepath.Join(inputs.protoDir, protoFileName(data.Names.System, inputs.tag))
+			lockPath := schema + ".lock"
+
+			lock, lockErr := readProtoLock(filepath.Join(data.Dir, lockPath))
+			if lockErr != nil {
+				return "", nil, lockErr
+			}
+
+			names := make([]string, len(fields))
+			seen := map[string]string{}
+			for i, field := range fields {
+				if other, ok := seen[field.Names.Field]; ok {
+					return "", nil, fmt.Errorf("%s: fields %s and %s are both named %q in proto",
+						data.Names.Public, other, field.Names.Public, field.Names.Field)
+				}
+				seen[field.Names.Field] = field.Names.Public
+				names[i] = field.Names.Field
+			}
+
+			lock.Message = message
+			numbers := lock.assign(names)
+
+			tmplData := tmplDataProto{
+				Data: inspect.Data{
+					Pkg:     data.Pkg,
+					Names:   data.Names,
+					Imports: imports,
+				},
+				Tag:          inputs.tag,
+				Message:      message,
+				Proto:        alias,
+				ProtoPackage: protoPackage,
+				GoPackage:    inputs.goPackage,
+			}
+
+			mapper := newProtoMapper(inputs.tag, alias, data)
+			own := protoFileName(data.Names.System, inputs.tag)
+			for i, field := range fields {
+				pf, converters, goImports, schemas, err := mapper.field(field)
+				if err != nil {
+					return "", nil, fmt.Errorf("%s.%s: %s", data.Names.Public, field.Names.Public, err)
+				}
+
+				pf.Number = numbers[i]
+				pf.To = fmt.Sprintf(pf.To, data.Names.Short)
+				tmplData.Fields = append(tmplData.Fields, pf)
+				tmplData.Converters = append(tmplData.Converters, converters...)
+
+				for _, goImport := range goImports {
+					tmplData.Imports.Use(goImport, protoGoImports[goImport])
+				}
+				for _, s := range schemas {
+					if s != own && !contains(tmplData.Schemas, s) {
+						tmplData.Schemas = append(tmplData.Schemas, s)
+					}
+				}
+			}
+
+			if mapper.encoder.err != nil {
+				return "", nil, mapper.encoder.err
+			}
+
+			tmplData.ReservedNumbers, tmplData.ReservedNames = lock.reserved()
+
+			lockSrc, encodeErr := lock.encode()
+			if encodeErr != nil {
+				return "", nil, encodeErr
+			}
+
+			files = []cli.File{
+				{Name: "proto-schema", Path: schema, Template: tmplProtoSchema},
+				{Path: lockPath, Content: lockSrc},
+			}
+
+			return tmplProto, tmplData, nil
+		},
+	}
+}
+
+// protoGoPackage splits a go_package option, e.g. example.com/api/userpb or
+// example.com/api/v1;userpb, into the import path and package name
+func protoGoPackage(option string) (string, string) {
+	if i := strings.Index(option, ";"); i >= 0 {
+		return option[:i], option[i+1:]
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return -1
+	}, path.Base(option))
+	return option, name
+}
+
+type tmplDataProto struct {
+	inspect.Data
+	Tag             string
+	Message         string
+	Proto           string
+	ProtoPackage    string
+	GoPackage       string
+	Fields          []tmplProtoField
+	Converters      []tmplConverter
+	Schemas         []string
+	ReservedNumbers []string
+	ReservedNames   []string
+}
+
+var tmplProto = `
+{{$ := .Names}}
+// This file is auto-generated by makes-code ... do not edit
+
+package {{.Pkg}}
+
+{{if not .Imports.Empty}}
+import ({{range .Imports.Groups}}
+{{range .}}  {{.}}
+{{end -}}
+{{end}})
+{{end}}
+
+func To{{$.Public}}Proto{{.Tag}}({{$.Short}} {{$.Public}}) *{{.Proto}}.{{.Message}} {
+	if {{$.Short}} == nil {
+		return nil
+	}
+
+	return &{{.Proto}}.{{.Message}}{
+{{range .Fields}}    {{.GoName}}: {{.To}},
+{{end -}}
+	}
+}
+
+func {{$.Public}}FromProto{{.Tag}}(msg *{{.Proto}}.{{.Message}}) ({{$.Public}}, error) {
+	if msg == nil {
+		return nil, nil
+	}
+
+	builder := New{{$.Public}}Builder()
+{{range .Fields}}{{if .Fails}}
+	{{.Names.Private}}, {{.Names.Private}}Err := {{.From}}
+	if {{.Names.Private}}Err != nil {
+		return nil, {{.Names.Private}}Err
+	}
+	builder.With{{.Names.Public}}({{.Names.Private}})
+
+{{else}}	builder.With{{.Names.Public}}({{.From}})
+{{end}}{{end}}
+	return builder.Build()
+}
+{{range .Converters}}
+func {{.Name}}(in {{.In}}) (out {{.Out}}{{if .Fails}}, err error{{end}}) {
+{{.Body}}	return out{{if .Fails}}, nil{{end}}
+}
+{{end}}`
+
+var tmplProtoSchema = `// This file is auto-generated by makes-code ... do not edit
+
+syntax = "proto3";
+
+package {{.ProtoPackage}};
+
+option go_package = "{{.GoPackage}}";
+{{if .Schemas}}
+{{range .Schemas}}import "{{.}}";
+{{end}}{{end}}
+message {{.Message}} {
+{{- if .ReservedNumbers}}
+  reserved {{join ", " .ReservedNumbers}};
+  reserved {{join ", " .ReservedNames}};
+{{end}}
+{{range .Fields}}  {{if .Label}}{{.Label}} {{end}}{{.ProtoType}} {{.Names.Field}} = {{.Number}};
+{{end -}}
+}
+`
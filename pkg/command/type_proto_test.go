@@ -0,0 +1,131 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// protoFiles hold a model and the protoc generated struct of its message,
+// written by hand since protoc is not required to run the tests
+var protoFiles = map[string]string{
+	"user.go": `package app
+
+// makes-code:model
+// makes-code:proto go-package=example.com/app/pb proto-package=acme.v1
+type User interface {
+	ID() string
+	Age() int
+	Tags() []string
+	Profile() Profile
+}
+
+// makes-code:model
+// makes-code:proto go-package=example.com/app/pb proto-package=acme.v1
+type Profile interface {
+	Bio() string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+	"pb/pb.go": `package pb
+
+type Profile struct{ Bio string }
+
+func (m *Profile) GetBio() string {
+	if m == nil {
+		return ""
+	}
+	return m.Bio
+}
+
+type User struct {
+	Id      string
+	Age     int64
+	Tags    []string
+	Profile *Profile
+}
+
+func (m *User) GetId() string        { return m.Id }
+func (m *User) GetAge() int64        { return m.Age }
+func (m *User) GetTags() []string    { return m.Tags }
+func (m *User) GetProfile() *Profile { return m.Profile }
+`,
+	"user_test.go": `package app
+
+import "testing"
+
+func TestRoundTrip(t *testing.T) {
+	profile := NewProfileBuilder().WithBio("mathematician").MustBuild()
+	u := NewUserBuilder().WithID("1").WithAge(36).WithTags([]string{"admin"}).WithProfile(profile).MustBuild()
+
+	msg := ToUserProto(u)
+	if msg.Id != "1" || msg.Age != 36 || msg.Profile.Bio != "mathematician" {
+		t.Errorf("encoded %+v", msg)
+	}
+
+	decoded, err := UserFromProto(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID() != "1" || decoded.Age() != 36 || decoded.Tags()[0] != "admin" || decoded.Profile().Bio() != "mathematician" {
+		t.Errorf("decoded %+v", decoded)
+	}
+
+	if u, err := UserFromProto(nil); u != nil || err != nil {
+		t.Errorf("decoded nil as %v, %v", u, err)
+	}
+}
+`,
+}
+
+func TestProtoRoundTrips(t *testing.T) {
+	dir := writeModule(t, protoFiles)
+	runGen(t)
+	goTest(t)
+
+	src, err := os.ReadFile(filepath.Join(dir, "user.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package acme.v1;",
+		`option go_package = "example.com/app/pb";`,
+		`import "profile.proto";`,
+		"string id = 1;",
+		"int64 age = 2;",
+		"repeated string tags = 3;",
+		"Profile profile = 4;",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("user.proto does not declare %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestProtoReservesRemovedFields(t *testing.T) {
+	dir := writeModule(t, protoFiles)
+	runGen(t)
+
+	user := filepath.Join(dir, "user.go")
+	src, err := os.ReadFile(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = []byte(strings.Replace(string(src), "\tAge() int\n", "", 1))
+	if err := os.WriteFile(user, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGen(t)
+
+	proto, err := os.ReadFile(filepath.Join(dir, "user.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"repeated string tags = 3;", "reserved 2;", `reserved "age";`} {
+		if !strings.Contains(string(proto), want) {
+			t.Errorf("user.proto does not declare %q:\n%s", want, proto)
+		}
+	}
+}
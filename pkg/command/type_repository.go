@@ -0,0 +1,181 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/makes-code/gen/internal/cli"
+	"github.com/makes-code/gen/internal/inspect"
+
+	mcli "github.com/mitchellh/cli"
+)
+
+const documentKey = "_id"
+
+func TypeRepository() (mcli.Command, error) {
+	return typeRepository(), nil
+}
+
+// typeRepository takes the flags of the document it stores, which it must
+// be given the same values as to select the same fields and names
+func typeRepository() *cli.CmdCodegen {
+	var inputs typeDocumentInputs
+
+	return &cli.CmdCodegen{
+		CmdMeta: cli.CmdMeta{
+			Name:     "repository",
+			Help:     "Generate a Mongo repository storing the document of the same tag and fields",
+			Synopsis: "Generate a Mongo repository",
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&inputs.tag, "tag", "", "")
+			inputs.fields.flags(fs)
+		},
+		FileName: func(systemName string) string {
+			var suffix string
+			if inputs.tag != "" {
+				suffix = "_" + strings.ToLower(inputs.tag)
+			}
+			return fmt.Sprintf("%s_gen_repository%s.go", systemName, suffix)
+		},
+		Runner: func(data inspect.Data) (string, interface{}, error) {
+			fields, fieldsErr := inputs.fields.apply(data.Fields, "bson", documentDefaults)
+			if fieldsErr != nil {
+				return "", nil, fieldsErr
+			}
+
+			var key *inspect.Field
+			for i, field := range fields {
+				if field.Names.Field == documentKey {
+					key = &fields[i]
+				}
+			}
+			if key == nil {
+				return "", nil, fmt.Errorf("%s: the repository needs a document field stored as %s", data.Names.Public, documentKey)
+			}
+
+			imports := data.Imports.New()
+			imports.Use("context", `"context"`)
+			imports.Use("bson", `"go.mongodb.org/mongo-driver/bson"`)
+			imports.Use("mongo", `"go.mongodb.org/mongo-driver/mongo"`)
+			imports.Use("options", `"go.mongodb.org/mongo-driver/mongo/options"`)
+			imports.Include(key.Type.Imports()...)
+			imports.Include(data.TypeParams.Imports()...)
+
+			return tmplRepository, tmplDataRepository{
+				Data: inspect.Data{
+					Pkg:        data.Pkg,
+					Names:      data.Names,
+					TypeParams: data.TypeParams,
+					Fields:     fields,
+					Imports:    imports,
+				},
+				Tag: inputs.tag,
+				Key: *key,
+			}, nil
+		},
+	}
+}
+
+type tmplDataRepository struct {
+	inspect.Data
+	Tag string
+	Key inspect.Field
+}
+
+var tmplRepository = `
+{{$ := .Names}}
+{{$tp := .TypeParams}}
+{{$ta := .TypeParams.Args}}
+{{$doc := printf "%sDocument%s" $.Public .Tag}}
+{{$repo := printf "%sRepository%s" $.Public .Tag}}
+// This file is auto-generated by makes-code ... do not edit
+
+package {{.Pkg}}
+
+{{if not .Imports.Empty}}
+import ({{range .Imports.Groups}}
+{{range .}}  {{.}}
+{{end -}}
+{{end}})
+{{end}}
+
+// {{$repo}} stores {{$.Display}} models as {{$doc}} in a Mongo collection
+type {{$repo}}{{$tp}} struct {
+	collection *mongo.Collection
+}
+
+func New{{$repo}}{{$tp}}(collection *mongo.Collection) *{{$repo}}{{$ta}} {
+	return &{{$repo}}{{$ta}}{collection: collection}
+}
+
+// Collection returns the collection the {{$.Display}} models are stored in
+func (repo *{{$repo}}{{$ta}}) Collection() *mongo.Collection {
+	return repo.collection
+}
+
+func (repo *{{$repo}}{{$ta}}) Insert(ctx context.Context, {{$.Short}} {{$.Public}}{{$ta}}) error {
+	_, err := repo.collection.InsertOne(ctx, To{{$doc}}({{$.Short}}))
+	return err
+}
+
+// FindByID returns the {{$.Display}} of the {{.Key.Names.Display}}, or mongo.ErrNoDocuments
+func (repo *{{$repo}}{{$ta}}) FindByID(ctx context.Context, {{.Key.Names.Private}} {{.Key.Type}}) ({{$.Public}}{{$ta}}, error) {
+	var doc {{$doc}}{{$ta}}
+	filter := bson.D{{"{{"}}Key: {{$doc}}Field{{.Key.Names.Public}}, Value: {{.Key.Names.Private}}{{"}}"}}
+	if err := repo.collection.FindOne(ctx, filter).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Find returns the {{$.Display}} models matching the filter
+func (repo *{{$repo}}{{$ta}}) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ({{$.Public}}s{{$ta}}, error) {
+	cursor, err := repo.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs {{$doc}}s{{$ta}}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs.{{$.Public}}s(), nil
+}
+
+// Update replaces the stored {{$.Display}} of the same {{.Key.Names.Display}}, or returns
+// mongo.ErrNoDocuments
+func (repo *{{$repo}}{{$ta}}) Update(ctx context.Context, {{$.Short}} {{$.Public}}{{$ta}}) error {
+	filter := bson.D{{"{{"}}Key: {{$doc}}Field{{.Key.Names.Public}}, Value: {{$.Short}}.{{.Key.Names.Public}}(){{"}}"}}
+	result, err := repo.collection.ReplaceOne(ctx, filter, To{{$doc}}({{$.Short}}))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Upsert replaces the stored {{$.Display}} of the same {{.Key.Names.Display}}, inserting it
+// if there is none
+func (repo *{{$repo}}{{$ta}}) Upsert(ctx context.Context, {{$.Short}} {{$.Public}}{{$ta}}) error {
+	filter := bson.D{{"{{"}}Key: {{$doc}}Field{{.Key.Names.Public}}, Value: {{$.Short}}.{{.Key.Names.Public}}(){{"}}"}}
+	_, err := repo.collection.ReplaceOne(ctx, filter, To{{$doc}}({{$.Short}}), options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete removes the {{$.Display}} of the {{.Key.Names.Display}}, or returns mongo.ErrNoDocuments
+func (repo *{{$repo}}{{$ta}}) Delete(ctx context.Context, {{.Key.Names.Private}} {{.Key.Type}}) error {
+	filter := bson.D{{"{{"}}Key: {{$doc}}Field{{.Key.Names.Public}}, Value: {{.Key.Names.Private}}{{"}}"}}
+	result, err := repo.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+`
@@ -0,0 +1,67 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepositoryCompiles(t *testing.T) {
+	files := repoModule(t)
+	files["user.go"] = `package app
+
+// makes-code:model
+// makes-code:document
+// makes-code:repository
+// makes-code:document tag=Summary strict include=ID,Name
+// makes-code:repository tag=Summary
+type User interface {
+	ID() string
+	Name() string
+	Tags() []string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`
+	files["use.go"] = `package app
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func use(ctx context.Context, collection *mongo.Collection, u User) error {
+	repo := NewUserRepository(collection)
+	if err := repo.Insert(ctx, u); err != nil {
+		return err
+	}
+	if _, err := repo.FindByID(ctx, u.ID()); err != nil {
+		return err
+	}
+	if _, err := NewUserRepositorySummary(collection).Find(ctx, UserFilter().D()); err != nil {
+		return err
+	}
+	return repo.Delete(ctx, u.ID())
+}
+`
+	writeModule(t, files)
+
+	runGen(t)
+	goVet(t)
+}
+
+func TestRepositoryNeedsKey(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+type User interface {
+	Name() string
+}
+`,
+	})
+
+	err := generate(t, typeRepository(), "User")
+	if err == nil || !strings.Contains(err.Error(), "needs a document field stored as _id") {
+		t.Errorf("err = %v, want the missing key reported", err)
+	}
+}
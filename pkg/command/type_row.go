@@ -0,0 +1,288 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/makes-code/gen/internal/cli"
+	"github.com/makes-code/gen/internal/inspect"
+
+	mcli "github.com/mitchellh/cli"
+)
+
+const defaultRowKey = "ID"
+
+type typeRowInputs struct {
+	tag         string
+	table       string
+	key         string
+	placeholder string
+	fields      fieldFilter
+}
+
+func TypeRow() (mcli.Command, error) {
+	return typeRow(), nil
+}
+
+func typeRow() *cli.CmdCodegen {
+	var inputs typeRowInputs
+
+	return &cli.CmdCodegen{
+		CmdMeta: cli.CmdMeta{
+			Name:     "row",
+			Help:     "Generate a database/sql row model",
+			Synopsis: "Generate a database/sql row model",
+		},
+		FileName: func(systemName string) string {
+			var suffix string
+			if inputs.tag != "" {
+				suffix = "_" + strings.ToLower(inputs.tag)
+			}
+			return fmt.Sprintf("%s_gen_row%s.go", systemName, suffix)
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&inputs.tag, "tag", "", "")
+			fs.StringVar(&inputs.table, "table", "", "")
+			fs.StringVar(&inputs.key, "key", "", "")
+			fs.StringVar(&inputs.placeholder, "placeholder", "?", "")
+			inputs.fields.flags(fs)
+		},
+		Runner: func(data inspect.Data) (string, interface{}, error) {
+			fields, fieldsErr := inputs.fields.apply(data.Fields, "db", nil)
+			if fieldsErr != nil {
+				return "", nil, fieldsErr
+			}
+
+			if inputs.placeholder != "?" && inputs.placeholder != "$" {
+				return "", nil, fmt.Errorf("unknown placeholder %q, use ? or $", inputs.placeholder)
+			}
+
+			for _, field := range fields {
+				if !isRowColumn(field.Type, data.TypeParams) {
+					return "", nil, fmt.Errorf("row field %s: database/sql cannot store a %s, "+
+						"use a type implementing sql.Scanner and driver.Valuer", field.Names.Public, field.Type)
+				}
+			}
+
+			imports := data.Imports.New()
+			imports.Use("sql", `"database/sql"`)
+			for _, field := range fields {
+				imports.Include(field.Type.Imports()...)
+			}
+			imports.Include(data.TypeParams.Imports()...)
+
+			table := inputs.table
+			if table == "" {
+				table = cli.Pluralize(data.Names.System)
+			}
+
+			tmplData := tmplDataRow{
+				Data: inspect.Data{
+					Pkg:        data.Pkg,
+					Names:      data.Names,
+					TypeParams: data.TypeParams,
+					Fields:     fields,
+					Imports:    imports,
+				},
+				Tag:   inputs.tag,
+				Table: table,
+			}
+
+			key := inputs.key
+			if key == "" {
+				key = defaultRowKey
+			}
+
+			columns := make([]string, len(fields))
+			var values []string
+			for i, field := range fields {
+				columns[i] = field.Names.Field
+				if field.Names.Public == key {
+					tmplData.Key = &fields[i]
+					continue
+				}
+				tmplData.Values = append(tmplData.Values, field)
+				values = append(values, field.Names.Field)
+			}
+
+			if tmplData.Key == nil && inputs.key != "" {
+				return "", nil, fmt.Errorf("key references unknown field %q", inputs.key)
+			}
+
+			tmplData.Columns = strings.Join(columns, ", ")
+			tmplData.Insert = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				table, tmplData.Columns, strings.Join(placeholders(inputs.placeholder, 1, len(columns)), ", "))
+
+			if tmplData.Key != nil {
+				sets := placeholders(inputs.placeholder, 1, len(values)+1)
+				for i, v := range values {
+					sets[i] = v + " = " + sets[i]
+				}
+				tmplData.Update = fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s",
+					table, strings.Join(sets[:len(values)], ", "), tmplData.Key.Names.Field, sets[len(values)])
+			}
+
+			return tmplRow, tmplData, nil
+		},
+	}
+}
+
+// isRowColumn reports whether database/sql scans and stores values of the
+// type as they are: the basic types, []byte, time.Time, the types declaring
+// the Scan and Value methods of sql.Scanner and driver.Valuer, and pointers
+// to those, which are NULL when nil. The type parameters of a generic row
+// are left to its instantiation.
+func isRowColumn(t inspect.FieldType, params inspect.TypeParams) bool {
+	if params.Has(t.String()) {
+		return true
+	}
+
+	if ref, ok := t.Named(); ok {
+		if ref.HasMethod("Scan") && ref.HasMethod("Value") {
+			return true
+		}
+		if ref.PkgPath() == "time" && ref.Name == "Time" {
+			return true
+		}
+	}
+
+	switch t.Kind() {
+	case inspect.KindString, inspect.KindBool:
+		return true
+	case inspect.KindNumber:
+		return !strings.HasPrefix(t.Basic(), "complex")
+	case inspect.KindSlice:
+		elem := t.Elem()
+		return elem != nil && (elem.Basic() == "byte" || elem.Basic() == "uint8")
+	case inspect.KindNilable:
+		elem := t.Elem()
+		return elem != nil && elem.Kind() != inspect.KindNilable && isRowColumn(elem, params)
+	}
+	return false
+}
+
+// placeholders returns the n query parameters starting at the position,
+// either ? or the numbered $1 of Postgres
+func placeholders(style string, start, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = "?"
+		if style == "$" {
+			out[i] = fmt.Sprintf("$%d", start+i)
+		}
+	}
+	return out
+}
+
+type tmplDataRow struct {
+	inspect.Data
+	Tag     string
+	Table   string
+	Columns string
+	Insert  string
+	Update  string
+	Key     *inspect.Field
+	Values  []inspect.Field
+}
+
+var tmplRow = `
+{{$ := .Names}}
+{{$tp := .TypeParams}}
+{{$ta := .TypeParams.Args}}
+{{$row := printf "%sRow%s" $.Public .Tag}}
+// This file is auto-generated by makes-code ... do not edit
+
+package {{.Pkg}}
+
+{{if not .Imports.Empty}}
+import ({{range .Imports.Groups}}
+{{range .}}  {{.}}
+{{end -}}
+{{end}})
+{{end}}
+
+const (
+	{{$row}}Table   = {{quote .Table}}
+	{{$row}}Columns = {{quote .Columns}}
+{{range .Fields}}  {{$row}}Column{{.Names.Public}} = {{quote .Names.Field}}
+{{end -}}
+)
+
+type {{$row}}s{{$tp}} []*{{$row}}{{$ta}}
+
+type {{$row}}{{$tp}} struct {
+	{{$.Private}}Data{{$ta}}
+}
+
+type {{$.Private}}Row{{.Tag}}{{$tp}} struct {
+{{range .Fields}} {{.Names.Public}} {{.Type}} {{.Tag "db"}}
+{{end -}}
+}
+
+func To{{$row}}{{$tp}}({{$.Short}} {{$.Public}}{{$ta}}) *{{$row}}{{$ta}} {
+	return &{{$row}}{{$ta}}{{"{"}}{{$.Private}}Data{{$ta}}{{"{"}}
+{{range .Fields}}    {{.Names.Private}}: {{$.Short}}.{{.Names.Public}}(),
+{{end -}}
+	{{"}"}}{{"}"}}
+}
+
+// Scan reads the current row of rows, selecting the {{$row}}Columns
+func ({{$.Short}} *{{$row}}{{$ta}}) Scan(rows *sql.Rows) error {
+	var tmp {{$.Private}}Row{{.Tag}}{{$ta}}
+	if err := rows.Scan({{range $i, $f := .Fields}}{{if $i}}, {{end}}&tmp.{{$f.Names.Public}}{{end}}); err != nil {
+		return err
+	}
+
+	{{$.Short}}.{{$.Private}}Data = {{$.Private}}Data{{$ta}}{
+{{range .Fields}}    {{.Names.Private}}: tmp.{{.Names.Public}},
+{{end -}}
+	}
+	return nil
+}
+
+// Scan{{$row}}s reads the remaining rows of rows, selecting the {{$row}}Columns
+func Scan{{$row}}s{{$tp}}(rows *sql.Rows) ({{$row}}s{{$ta}}, error) {
+	var out {{$row}}s{{$ta}}
+	for rows.Next() {
+		var row {{$row}}{{$ta}}
+		if err := row.Scan(rows); err != nil {
+			return nil, err
+		}
+		out = append(out, &row)
+	}
+	return out, rows.Err()
+}
+
+// Insert returns the statement inserting the row along with its arguments
+func ({{$.Short}} {{$row}}{{$ta}}) Insert() (string, []interface{}) {
+	return {{quote .Insert}}, []interface{}{
+{{range .Fields}}    {{$.Short}}.{{.Names.Private}},
+{{end -}}
+	}
+}
+{{if .Key}}
+// Update returns the statement updating the row by its {{.Key.Names.Display}}, along with its arguments
+func ({{$.Short}} {{$row}}{{$ta}}) Update() (string, []interface{}) {
+	return {{quote .Update}}, []interface{}{
+{{range .Values}}    {{$.Short}}.{{.Names.Private}},
+{{end}}    {{$.Short}}.{{.Key.Names.Private}},
+	}
+}
+{{end}}
+func To{{$row}}s{{$tp}}({{$.Private}}s {{$.Public}}s{{$ta}}) {{$row}}s{{$ta}} {
+	rows := make({{$row}}s{{$ta}}, len({{$.Private}}s))
+	for i, {{$.Private}} := range {{$.Private}}s {
+		rows[i] = To{{$row}}({{$.Private}})
+	}
+	return rows
+}
+
+func (rows {{$row}}s{{$ta}}) {{$.Public}}s() {{$.Public}}s{{$ta}} {
+	{{$.Private}}s := make({{$.Public}}s{{$ta}}, len(rows))
+	for i, row := range rows {
+		{{$.Private}}s[i] = row
+	}
+	return {{$.Private}}s
+}
+`
@@ -0,0 +1,177 @@
+package command
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const sqliteModule = `module example.com/app
+
+go 1.25
+
+require github.com/mattn/go-sqlite3 v1.14.33
+`
+
+const sqliteSum = `github.com/mattn/go-sqlite3 v1.14.33 h1:A5blZ5ulQo2AtayQ9/limgHEkFreKj1Dv226a1K73s0=
+github.com/mattn/go-sqlite3 v1.14.33/go.mod h1:Uh1q+B4BYcTPb+yiD3kU8Ct7aC0hY9fxUwlHK0RXw+Y=
+`
+
+const rowModels = `package app
+
+import (
+	"database/sql"
+	"time"
+)
+
+// makes-code:model
+// makes-code:row
+type User interface {
+	ID() int64
+	Name() string // db:"full_name"
+	Email() *string
+	Nickname() sql.NullString
+	Avatar() []byte
+	CreatedAt() time.Time
+}
+
+func prebuild(builder interface{}) error { return nil }
+`
+
+const rowTest = `package app
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestUserRowRoundTrip(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, full_name TEXT, email TEXT, nickname TEXT, avatar BLOB, created_at TIMESTAMP)"); err != nil {
+		t.Fatal(err)
+	}
+
+	email := "ada@example.com"
+	created := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	users := Users{
+		NewUserBuilder().WithID(1).WithName("Ada").WithEmail(&email).
+			WithNickname(sql.NullString{String: "ada", Valid: true}).WithAvatar([]byte{1, 2}).
+			WithCreatedAt(created).MustBuild(),
+		NewUserBuilder().WithID(2).WithName("Grace").WithCreatedAt(created).MustBuild(),
+	}
+
+	for _, row := range ToUserRows(users) {
+		query, args := row.Insert()
+		if _, err := db.Exec(query, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var nulls int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users WHERE email IS NULL AND nickname IS NULL").Scan(&nulls); err != nil {
+		t.Fatal(err)
+	}
+	if nulls != 1 {
+		t.Errorf("%d rows store NULL for the nil email and the invalid nickname, want 1", nulls)
+	}
+
+	rows, err := db.Query("SELECT " + UserRowColumns + " FROM " + UserRowTable + " ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	scanned, err := ScanUserRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != len(users) {
+		t.Fatalf("scanned %d rows, want %d", len(scanned), len(users))
+	}
+	for i, row := range scanned {
+		if !reflect.DeepEqual(&row.userData, users[i]) {
+			t.Errorf("scanned %#v, want %#v", row.userData, users[i])
+		}
+	}
+
+	updated := ToUserRow(users[1].(*userData).withEmail(&email))
+	query, args := updated.Update()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if err := db.QueryRow("SELECT email FROM users WHERE id = 2").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != email {
+		t.Errorf("updated email = %q, want %q", got, email)
+	}
+}
+
+func (u *userData) withEmail(email *string) User {
+	c := *u
+	c.email = email
+	return &c
+}
+`
+
+func TestRowRoundTripsThroughSQLite(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("go-sqlite3 needs cgo")
+	}
+
+	writeModule(t, map[string]string{
+		"go.mod":      sqliteModule,
+		"go.sum":      sqliteSum,
+		"models.go":   rowModels,
+		"row_test.go": rowTest,
+	})
+
+	runGen(t)
+	goTest(t)
+}
+
+func TestRowRejectsUnsupportedColumns(t *testing.T) {
+	writeModule(t, map[string]string{
+		"models.go": `package app
+
+type User interface {
+	ID() int64
+	Tags() []string
+	Labels() map[string]string
+	Profile() Profile
+	Ratio() *complex128
+}
+
+type Profile interface {
+	Bio() string
+}
+`,
+	})
+
+	for _, field := range []string{"Tags", "Labels", "Profile", "Ratio"} {
+		t.Run(field, func(t *testing.T) {
+			var excluded []string
+			for _, other := range []string{"Tags", "Labels", "Profile", "Ratio"} {
+				if other != field {
+					excluded = append(excluded, "-x", other)
+				}
+			}
+
+			err := generate(t, typeRow(), "User", append([]string{"-dry-run"}, excluded...)...)
+			if err == nil || !strings.Contains(err.Error(), "row field "+field) {
+				t.Errorf("err = %v, want the %s field rejected", err, field)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/makes-code/gen/internal/cli"
+	"github.com/makes-code/gen/internal/inspect"
+
+	mcli "github.com/mitchellh/cli"
+	"gopkg.in/yaml.v3"
+)
+
+type typeSchemaInputs struct {
+	tag     string
+	format  string
+	openAPI bool
+	fields  fieldFilter
+}
+
+func TypeSchema() (mcli.Command, error) {
+	return typeSchema(), nil
+}
+
+// typeSchema describes the fields of the payload of the same tag, selected
+// by the payload output marked or configured for the type. The field flags
+// select them otherwise, for the payloads generated by hand.
+func typeSchema() *cli.CmdCodegen {
+	var inputs typeSchemaInputs
+
+	return &cli.CmdCodegen{
+		CmdMeta: cli.CmdMeta{
+			Name:     "schema",
+			Help:     "Generate the JSON Schema or OpenAPI component of the payload of the same tag",
+			Synopsis: "Generate a payload JSON Schema",
+		},
+		FileName: func(systemName string) string {
+			return schemaFileName(systemName, inputs.tag, inputs.format)
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&inputs.tag, "tag", "", "")
+			fs.StringVar(&inputs.format, "format", "json", "")
+			fs.BoolVar(&inputs.openAPI, "openapi", false, "")
+			inputs.fields.flags(fs)
+		},
+		Runner: func(data inspect.Data) (string, interface{}, error) {
+			if inputs.format != "json" && inputs.format != "yaml" {
+				return "", nil, fmt.Errorf("unknown schema format %q, use json or yaml", inputs.format)
+			}
+
+			fields, fieldsErr := inputs.payloadFields(data)
+			if fieldsErr != nil {
+				return "", nil, fieldsErr
+			}
+
+			encoder := newModelEncoder("payload", inputs.tag, data)
+			name := data.Names.Public + encoder.suffix
+
+			mapper := schemaMapper{
+				encoder: encoder,
+				schemas: newModelEncoder("schema", inputs.tag, data),
+				ref: func(model inspect.TypeRef) (string, error) {
+					if inputs.openAPI {
+						return "#/components/schemas/" + model.Name + encoder.suffix, nil
+					}
+
+					// the schema of the model is written next to its
+					// package, referenced relative to this one
+					dir, err := model.Dir()
+					if err != nil {
+						return "", err
+					}
+					rel, err := filepath.Rel(data.Dir, dir)
+					if err != nil {
+						return "", err
+					}
+
+					system := inspect.NewNames(model.Name, inspect.NamesOptions{}).System
+					return path.Join(filepath.ToSlash(rel), schemaFileName(system, inputs.tag, inputs.format)), nil
+				},
+			}
+
+			object, objectErr := mapper.object(fields)
+			if objectErr != nil {
+				return "", nil, fmt.Errorf("%s.%s", data.Names.Public, objectErr)
+			}
+			if encoder.err != nil {
+				return "", nil, encoder.err
+			}
+			if mapper.schemas.err != nil {
+				return "", nil, mapper.schemas.err
+			}
+
+			var schema schemaObject
+			if inputs.openAPI {
+				schema = schemaObject{{"components", schemaObject{
+					{"schemas", schemaObject{{name, object}}},
+				}}}
+			} else {
+				schema = append(schemaObject{
+					{"$schema", jsonSchemaDialect},
+					{"title", name},
+				}, object...)
+			}
+
+			src, srcErr := encodeSchema(schema, inputs.format)
+			if srcErr != nil {
+				return "", nil, srcErr
+			}
+
+			return tmplSchema, tmplDataSchema{
+				Data: inspect.Data{
+					Pkg:    data.Pkg,
+					Names:  data.Names,
+					Fields: fields,
+				},
+				Tag:    inputs.tag,
+				Name:   name,
+				Format: inputs.format,
+				Schema: schema,
+				Source: string(src),
+			}, nil
+		},
+	}
+}
+
+// payloadFields returns the fields of the payload the schema describes
+func (inputs typeSchemaInputs) payloadFields(data inspect.Data) ([]inspect.Field, error) {
+	typeName := data.Source
+	if typeName == "" {
+		typeName = data.Names.Public
+	}
+
+	args, ok := data.Plan.Args(data.PkgPath, typeName, "payload", inputs.tag)
+	if !ok {
+		return inputs.fields.apply(data.Fields, "json", nil)
+	}
+
+	if inputs.fields.set() {
+		return nil, fmt.Errorf("%s: the schema takes the fields of the %s payload output, drop -i, -x and -strict",
+			data.Names.Public, data.Names.Public+"Payload"+inputs.tag)
+	}
+
+	var payload typePayloadInputs
+	if err := newTypePayload(&payload).ParseOutput(args); err != nil {
+		return nil, err
+	}
+	return payload.fields.apply(data.Fields, "json", nil)
+}
+
+func encodeSchema(schema schemaObject, format string) ([]byte, error) {
+	if format == "json" {
+		src, err := json.MarshalIndent(schema, "", "  ")
+		return append(src, '\n'), err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(schema); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func schemaFileName(systemName, tag, format string) string {
+	var suffix string
+	if tag != "" {
+		suffix = "_" + strings.ToLower(tag)
+	}
+	return fmt.Sprintf("%s_gen_schema%s.%s", systemName, suffix, format)
+}
+
+// tmplDataSchema holds the schema both as data and encoded, which the
+// built-in template writes as is
+type tmplDataSchema struct {
+	inspect.Data
+	Tag    string
+	Name   string
+	Format string
+	Schema schemaObject
+	Source string
+}
+
+var tmplSchema = `{{.Source}}`
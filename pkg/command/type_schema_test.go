@@ -0,0 +1,245 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var schemaFiles = map[string]string{
+	"user.go": `package app
+
+import "example.com/app/profile"
+
+// makes-code:payload
+// makes-code:schema
+// makes-code:schema openapi format=yaml
+type User interface {
+	Age() uint8
+	Size() uint32
+	Count() uint64
+	Profile() profile.Profile
+	Friends() []profile.Profile
+}
+`,
+	"profile/profile.go": `package profile
+
+// makes-code:payload
+// makes-code:schema
+type Profile interface {
+	Bio() string
+}
+`,
+}
+
+// readSchema decodes the properties of the JSON Schema written to the path
+func readSchema(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		Properties map[string]interface{}
+	}
+	if err := json.Unmarshal(src, &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema.Properties
+}
+
+func assertSchema(t *testing.T, properties map[string]interface{}, name, want string) {
+	t.Helper()
+
+	var wantSchema interface{}
+	if err := json.Unmarshal([]byte(want), &wantSchema); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(properties[name], wantSchema) {
+		got, _ := json.Marshal(properties[name])
+		t.Errorf("%s schema = %s, want %s", name, got, want)
+	}
+}
+
+func TestSchemaMapsUnsignedIntegers(t *testing.T) {
+	dir := writeModule(t, schemaFiles)
+	runGen(t)
+
+	properties := readSchema(t, filepath.Join(dir, "user_gen_schema.json"))
+	assertSchema(t, properties, "age", `{"type": "integer", "format": "int32", "minimum": 0}`)
+	assertSchema(t, properties, "size", `{"type": "integer", "format": "int64", "minimum": 0}`)
+	assertSchema(t, properties, "count", `{"type": "integer", "minimum": 0}`)
+}
+
+func TestSchemaReferencesNestedModels(t *testing.T) {
+	dir := writeModule(t, schemaFiles)
+	runGen(t)
+
+	properties := readSchema(t, filepath.Join(dir, "user_gen_schema.json"))
+	ref := `{"oneOf": [{"$ref": "profile/profile_gen_schema.json"}, {"type": "null"}]}`
+	assertSchema(t, properties, "profile", ref)
+	assertSchema(t, properties, "friends", `{"type": ["array", "null"], "items": `+ref+`}`)
+
+	if _, err := os.Stat(filepath.Join(dir, "profile", "profile_gen_schema.json")); err != nil {
+		t.Errorf("the referenced schema is not written: %s", err)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "user_gen_schema.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `          oneOf:
+            - $ref: '#/components/schemas/ProfilePayload'
+            - type: "null"
+`
+	if !strings.Contains(string(src), want) {
+		t.Errorf("the OpenAPI component does not reference ProfilePayload as nullable:\n%s", src)
+	}
+}
+
+func TestSchemaMapsNamedTypes(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.go": `package app
+
+import (
+	"encoding/json"
+	"net"
+)
+
+type Tags []string
+
+type Blob []byte
+
+// makes-code:payload
+// makes-code:schema
+type User interface {
+	Addr() net.IP
+	Labels() Tags
+	Avatar() Blob
+	Raw() json.RawMessage
+}
+`,
+	})
+	runGen(t)
+
+	properties := readSchema(t, filepath.Join(dir, "user_gen_schema.json"))
+	assertSchema(t, properties, "addr", `{"type": "string"}`)
+	assertSchema(t, properties, "labels", `{"type": ["array", "null"], "items": {"type": "string"}}`)
+	assertSchema(t, properties, "avatar", `{"type": "string", "contentEncoding": "base64"}`)
+	assertSchema(t, properties, "raw", `{}`)
+}
+
+func TestSchemaTakesPayloadFields(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"makes-code.yaml": "types:\n  User:\n    payload:\n      - tag: Admin\n        strict: true\n        include: [ID, Age]\n",
+		"user.go": `package app
+
+import "time"
+
+// makes-code:payload tag=Partial strict include=ID,Name=n,omitempty
+// makes-code:schema tag=Partial
+// makes-code:payload tag=Admin include=Name
+// makes-code:schema tag=Admin
+type User interface {
+	ID() string
+	Name() string
+	Age() int
+	Created() time.Time
+}
+`,
+	})
+	runGen(t)
+
+	src, err := os.ReadFile(filepath.Join(dir, "user_gen_schema_partial.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "UserPayloadPartial",
+  "type": "object",
+  "properties": {
+    "id": {
+      "type": "string"
+    },
+    "n": {
+      "type": "string"
+    }
+  },
+  "required": [
+    "id"
+  ],
+  "additionalProperties": false
+}
+`
+	if string(src) != want {
+		t.Errorf("user_gen_schema_partial.json =\n%s\nwant\n%s", src, want)
+	}
+
+	// the configured include overrides the marker one
+	properties := readSchema(t, filepath.Join(dir, "user_gen_schema_admin.json"))
+	if len(properties) != 2 || properties["id"] == nil || properties["age"] == nil {
+		t.Errorf("admin schema properties = %v, want id and age", properties)
+	}
+
+	// single runs read the same outputs
+	if err := os.Remove(filepath.Join(dir, "user_gen_schema_partial.json")); err != nil {
+		t.Fatal(err)
+	}
+	if code := typeSchema().Run([]string{"-name", "User", "-tag", "Partial"}); code != 0 {
+		t.Fatalf("type schema exited with %d", code)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "user_gen_schema_partial.json")); string(got) != want {
+		t.Errorf("type schema wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSchemaRejectsFieldFlagsOfDeclaredPayload(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:payload strict include=ID
+// makes-code:schema include=Name
+type User interface {
+	ID() string
+	Name() string
+}
+`,
+	})
+
+	gen, err := Gen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := gen.Run(nil); code == 0 {
+		t.Error("gen accepted a schema selecting other fields than its payload")
+	}
+}
+
+func TestSchemaNeedsNestedSchemas(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:payload tag=Partial
+// makes-code:schema tag=Partial
+type User interface {
+	Profile() Profile
+}
+
+// makes-code:payload tag=Partial
+type Profile interface {
+	Bio() string
+}
+`,
+	})
+
+	err := generate(t, typeSchema(), "User", "-tag", "Partial")
+	if want := "User.Profile: nested model Profile has no schema of the same tag to reference"; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
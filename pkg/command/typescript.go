@@ -0,0 +1,184 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// tmplTSField is a property of the TypeScript interface of a payload
+type tmplTSField struct {
+	Name     string
+	Type     string
+	Optional bool
+}
+
+// tmplTSImport imports the interface of a nested payload from its file
+type tmplTSImport struct {
+	Name string
+	From string
+}
+
+// tsMapper maps the payload fields to the properties of a TypeScript
+// interface matching their JSON encoding
+type tsMapper struct {
+	encoder *modelEncoder
+	params  inspect.TypeParams
+	imports []tmplTSImport
+}
+
+func (m *tsMapper) fields(fields []inspect.Field) ([]tmplTSField, error) {
+	var out []tmplTSField
+
+	for _, f := range fields {
+		tag := f.Tags["json"]
+		if tag.Has("-") {
+			continue
+		}
+
+		t, err := m.tsType(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", f.Names.Public, err)
+		}
+
+		if tag.Has("string") {
+			switch f.Type.Kind() {
+			case inspect.KindString, inspect.KindNumber, inspect.KindBool:
+				t = "string"
+			}
+		}
+
+		name := f.Names.Field
+		if !tsIdentifier.MatchString(name) {
+			name = strconv.Quote(name)
+		}
+
+		out = append(out, tmplTSField{Name: name, Type: t, Optional: tag.Has("omitempty")})
+	}
+	return out, nil
+}
+
+// generics returns the type parameters of the interface, e.g. <K, V>
+func (m *tsMapper) generics() string {
+	if len(m.params) == 0 {
+		return ""
+	}
+	args := m.params.Args()
+	return "<" + args[1:len(args)-1] + ">"
+}
+
+func (m *tsMapper) tsType(t inspect.FieldType) (string, error) {
+	if m.params.Has(t.String()) {
+		return t.String(), nil
+	}
+
+	if ref, ok := t.Named(); ok {
+		switch {
+		case ref.PkgPath() == "time" && ref.Name == "Time":
+			return "string", nil
+		case ref.PkgPath() == "encoding/json" && ref.Name == "RawMessage":
+			return "unknown", nil
+		}
+
+		if _, ok := m.encoder.nested(t); ok {
+			name := ref.Name + m.encoder.suffix
+			m.addImport(name, "./"+tsFileName(inspect.NewNames(ref.Name, inspect.NamesOptions{}).System, m.encoder.tag))
+
+			if args := ref.Args(); len(args) > 0 {
+				tsArgs := make([]string, len(args))
+				for i, arg := range args {
+					tsArg, err := m.tsType(arg)
+					if err != nil {
+						return "", err
+					}
+					tsArgs[i] = tsArg
+				}
+				return name + "<" + strings.Join(tsArgs, ", ") + "> | null", nil
+			}
+			return name + " | null", nil
+		}
+
+		switch {
+		case ref.HasMethod("MarshalJSON"):
+			return "unknown", nil
+		case ref.HasMethod("MarshalText"):
+			return "string", nil
+		}
+	}
+
+	if s := t.Underlying().String(); s == "[]byte" || s == "[]uint8" {
+		return "string", nil
+	}
+
+	switch t.Kind() {
+	case inspect.KindString:
+		return "string", nil
+	case inspect.KindNumber:
+		if strings.HasPrefix(t.Basic(), "complex") {
+			return "", fmt.Errorf("type %s is not supported by JSON", t)
+		}
+		return "number", nil
+	case inspect.KindBool:
+		return "boolean", nil
+	}
+
+	if t.Elem() == nil {
+		return "unknown", nil
+	}
+
+	elem, err := m.tsType(t.Elem())
+	if err != nil {
+		return "", err
+	}
+
+	items := elem
+	if strings.Contains(elem, " | ") {
+		items = "(" + elem + ")"
+	}
+
+	switch t.Kind() {
+	case inspect.KindSlice:
+		return items + "[] | null", nil
+	case inspect.KindArray:
+		return items + "[]", nil
+	case inspect.KindMap:
+		return "Record<string, " + elem + "> | null", nil
+	}
+
+	if strings.HasSuffix(elem, " | null") {
+		return elem, nil
+	}
+	return elem + " | null", nil
+}
+
+func (m *tsMapper) addImport(name, from string) {
+	for _, i := range m.imports {
+		if i.Name == name {
+			return
+		}
+	}
+	m.imports = append(m.imports, tmplTSImport{Name: name, From: from})
+}
+
+func tsFileName(systemName, tag string) string {
+	var suffix string
+	if tag != "" {
+		suffix = "_" + strings.ToLower(tag)
+	}
+	return fmt.Sprintf("%s_payload%s", systemName, suffix)
+}
+
+var tmplPayloadTS = `// This file is auto-generated by makes-code ... do not edit
+{{if .TSImports}}
+{{range .TSImports}}import type { {{.Name}} } from {{quote .From}};
+{{end}}{{end}}
+export interface {{.Names.Public}}Payload{{.Tag}}{{.TSGenerics}} {
+{{range .TSFields}}  {{.Name}}{{if .Optional}}?{{end}}: {{.Type}};
+{{end -}}
+}
+`
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/makes-code/gen/internal/inspect"
+)
+
+const validateTag = "validate"
+
+// tmplValidation is a generated field check, failing when Check is true
+type tmplValidation struct {
+	Check   string
+	Message string
+
+	imports []string
+}
+
+// fieldValidations compiles the validate rules declared on the fields, e.g.
+// validate:"required,min=1,max=64,oneof=admin member", into the checks of the
+// generated Validate method. Length rules apply to strings, slices, arrays
+// and maps while numbers are compared by value.
+func fieldValidations(model inspect.Names, fields []inspect.Field) ([]tmplValidation, error) {
+	var validations []tmplValidation
+
+	for _, f := range fields {
+		rules, ok := f.Lookup(validateTag)
+		if !ok {
+			continue
+		}
+
+		for _, rule := range strings.Split(rules, ",") {
+			rule = strings.TrimSpace(rule)
+			if rule == "" {
+				continue
+			}
+
+			v, err := newValidation(f, rule)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %s", model.Public, f.Names.Public, err)
+			}
+
+			v.Message = fmt.Sprintf("%s %s %s", model.Display, f.Names.Display, v.Message)
+			validations = append(validations, v)
+		}
+	}
+
+	return validations, nil
+}
+
+func newValidation(f inspect.Field, rule string) (tmplValidation, error) {
+	value := "builder.data." + f.Names.Private
+	kind := f.Type.Kind()
+
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case "required":
+		v := tmplValidation{Check: requiredCheck(value, kind), Message: "is required"}
+		if strings.HasPrefix(v.Check, "reflect.") {
+			v.imports = []string{"reflect"}
+		}
+		return v, nil
+
+	case "min", "max", "len":
+		op := map[string]string{"min": "<", "max": ">", "len": "!="}[name]
+		desc := map[string]string{"min": "at least", "max": "at most", "len": "exactly"}[name]
+
+		switch kind {
+		case inspect.KindString, inspect.KindSlice, inspect.KindArray, inspect.KindMap:
+			if !isNumber("int", arg) {
+				return tmplValidation{}, fmt.Errorf("rule %s expects a length, got %q", name, arg)
+			}
+			return tmplValidation{
+				Check:   fmt.Sprintf("len(%s) %s %s", value, op, arg),
+				Message: fmt.Sprintf("must have a length of %s %s", desc, arg),
+			}, nil
+		case inspect.KindNumber:
+			if !isNumber(f.Type.Basic(), arg) {
+				return tmplValidation{}, fmt.Errorf("rule %s expects a number of type %s, got %q", name, f.Type.Basic(), arg)
+			}
+			return tmplValidation{
+				Check:   fmt.Sprintf("%s %s %s", value, op, arg),
+				Message: fmt.Sprintf("must be %s %s", desc, arg),
+			}, nil
+		}
+		return tmplValidation{}, fmt.Errorf("rule %s does not apply to %s", name, f.Type)
+
+	case "oneof":
+		options := strings.Fields(arg)
+		if len(options) == 0 {
+			return tmplValidation{}, fmt.Errorf("rule oneof expects a list of values")
+		}
+
+		checks := make([]string, len(options))
+		for i, o := range options {
+			switch kind {
+			case inspect.KindString:
+				checks[i] = fmt.Sprintf("%s != %s", value, strconv.Quote(o))
+			case inspect.KindNumber:
+				if !isNumber(f.Type.Basic(), o) {
+					return tmplValidation{}, fmt.Errorf("rule oneof expects numbers of type %s, got %q", f.Type.Basic(), o)
+				}
+				checks[i] = fmt.Sprintf("%s != %s", value, o)
+			default:
+				return tmplValidation{}, fmt.Errorf("rule oneof does not apply to %s", f.Type)
+			}
+		}
+
+		return tmplValidation{
+			Check:   strings.Join(checks, " && "),
+			Message: "must be one of " + strings.Join(options, ", "),
+		}, nil
+	}
+
+	return tmplValidation{}, fmt.Errorf("unknown validate rule %q", name)
+}
+
+func requiredCheck(value string, kind inspect.TypeKind) string {
+	switch kind {
+	case inspect.KindString:
+		return value + ` == ""`
+	case inspect.KindNumber:
+		return value + " == 0"
+	case inspect.KindBool:
+		return "!" + value
+	case inspect.KindSlice, inspect.KindMap:
+		return "len(" + value + ") == 0"
+	case inspect.KindNilable:
+		return value + " == nil"
+	}
+	return "reflect.ValueOf(" + value + ").IsZero()"
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestModelValidateChecksRuleArgs(t *testing.T) {
+	for _, tt := range []struct {
+		field, rule, err string
+	}{
+		{"Count() int", "max=1.5", `User.Count: rule max expects a number of type int, got "1.5"`},
+		{"Count() uint", "min=-1", `User.Count: rule min expects a number of type uint, got "-1"`},
+		{"Count() uint8", "max=256", `User.Count: rule max expects a number of type uint8, got "256"`},
+		{"Count() int8", "oneof=1 2.5", `User.Count: rule oneof expects numbers of type int8, got "2.5"`},
+		{"Name() string", "len=1.5", `User.Name: rule len expects a length, got "1.5"`},
+		{"Tags() []string", "min=x", `User.Tags: rule min expects a length, got "x"`},
+		{"Score() float32", "max=NaN", `User.Score: rule max expects a number of type float32, got "NaN"`},
+	} {
+		writeModule(t, map[string]string{
+			"user.go": fmt.Sprintf("package app\n\ntype User interface {\n\t// validate: %q\n\t%s\n}\n", tt.rule, tt.field),
+		})
+
+		err := generate(t, typeModel(), "User", "-validate", "-dry-run")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s %s: err = %v, want %q", tt.field, tt.rule, err, tt.err)
+		}
+	}
+}
+
+func TestModelValidate(t *testing.T) {
+	writeModule(t, map[string]string{
+		"user.go": `package app
+
+// makes-code:model validate
+type User interface {
+	// validate: "required,max=64"
+	Name() string
+	// validate: "min=1,max=0x10"
+	Level() uint8
+	// validate: "oneof=1.5 2.5"
+	Score() float64
+	// validate: "len=2"
+	Tags() []string
+}
+
+func prebuild(builder interface{}) error { return nil }
+`,
+		"user_test.go": `package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	_, err := NewUserBuilder().WithName("Ada").WithLevel(16).WithScore(1.5).WithTags([]string{"a", "b"}).Build()
+	if err != nil {
+		t.Fatalf("valid user: %s", err)
+	}
+
+	_, err = NewUserBuilder().WithLevel(17).WithScore(2).Build()
+	if err == nil {
+		t.Fatal("invalid user built")
+	}
+	for _, want := range []string{
+		"user name is required",
+		"user level must be at most 0x10",
+		"user score must be one of 1.5, 2.5",
+		"user tags must have a length of exactly 2",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %q, want %q", err, want)
+		}
+	}
+}
+`,
+	})
+
+	runGen(t)
+	goTest(t)
+}
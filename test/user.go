@@ -3,17 +3,23 @@ package types
 import "github.com/makes-code/gen/test/user"
 
 type User interface {
-	ID() string
+	ID() string // validate:"required"
+	// validate:"max=64"
 	Name() string
 	Identities() []user.Identity
 	Profile() user.Profile
 	Workspaces() map[string]user.Workspace
+
+	WithName(name string) User
+	WithIdentities(identities []user.Identity) User
+	Clone() User
+	Equal(other User) bool
 }
 
 func (builder *UserBuilder) Prebuild() error {
 	return nil
 }
 
-//go:generate go run ../main.go type model -repo test -name User
+//go:generate go run ../main.go type model -repo test -name User -validate -with -clone -equal -required ID
 //go:generate go run ../main.go type payload -repo test -name User -tag Partial -strict -i ID -i Name=n
 //go:generate go run ../main.go type document -repo test -name User -tag Partial -i Name=n -x Identities -x Profile -x Workspaces
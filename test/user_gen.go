@@ -3,6 +3,11 @@
 package types
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
 	"github.com/makes-code/gen/test/user"
 )
 
@@ -30,9 +35,176 @@ func (u *userData) Builder() *UserBuilder {
 		WithWorkspaces(u.workspaces)
 }
 
+// copy returns a copy of the user sharing none of its slices and maps
+func (u *userData) copy() *userData {
+	c := *u
+	c.identities = copyUserIdentities(u.identities)
+	c.workspaces = copyUserWorkspaces(u.workspaces)
+	return &c
+}
+
+// WithID returns a copy of the user with the id
+func (u *userData) WithID(id string) User {
+	c := u.copy()
+	c.id = id
+	return c
+}
+
+// WithName returns a copy of the user with the name
+func (u *userData) WithName(name string) User {
+	c := u.copy()
+	c.name = name
+	return c
+}
+
+// WithIdentities returns a copy of the user with the identities
+func (u *userData) WithIdentities(identities []user.Identity) User {
+	c := u.copy()
+	c.identities = copyUserIdentities(identities)
+	return c
+}
+
+// WithProfile returns a copy of the user with the profile
+func (u *userData) WithProfile(profile user.Profile) User {
+	c := u.copy()
+	c.profile = profile
+	return c
+}
+
+// WithWorkspaces returns a copy of the user with the workspaces
+func (u *userData) WithWorkspaces(workspaces map[string]user.Workspace) User {
+	c := u.copy()
+	c.workspaces = copyUserWorkspaces(workspaces)
+	return c
+}
+
+func copyUserIdentities(in []user.Identity) (out []user.Identity) {
+	if in != nil {
+		out = make([]user.Identity, len(in))
+		copy(out, in)
+	}
+	return out
+}
+
+func copyUserWorkspaces(in map[string]user.Workspace) (out map[string]user.Workspace) {
+	if in != nil {
+		out = make(map[string]user.Workspace, len(in))
+		for k0, v0 := range in {
+			out[k0] = v0
+		}
+	}
+	return out
+}
+
+// Clone returns a deep copy of the user
+func (u *userData) Clone() User {
+	c := *u
+	c.identities = cloneUserIdentities(u.identities)
+	c.workspaces = cloneUserWorkspaces(u.workspaces)
+	return &c
+}
+
+func cloneUserIdentities(in []user.Identity) (out []user.Identity) {
+	if in != nil {
+		out = make([]user.Identity, len(in))
+		copy(out, in)
+	}
+	return out
+}
+
+func cloneUserWorkspaces(in map[string]user.Workspace) (out map[string]user.Workspace) {
+	if in != nil {
+		out = make(map[string]user.Workspace, len(in))
+		for k0, v0 := range in {
+			out[k0] = v0
+		}
+	}
+	return out
+}
+
+// Equal reports whether the other user holds equal values, nil and
+// empty slices and maps being equal
+func (u *userData) Equal(other User) bool {
+	if other == nil {
+		return false
+	}
+	return u.id == other.ID() &&
+		u.name == other.Name() &&
+		equalUserIdentities(u.identities, other.Identities()) &&
+		reflect.DeepEqual(u.profile, other.Profile()) &&
+		equalUserWorkspaces(u.workspaces, other.Workspaces())
+}
+
+// UserFieldChange is a user field holding different values in
+// two user models, as returned by Diff
+type UserFieldChange struct {
+	Field string
+	From  interface{}
+	To    interface{}
+}
+
+func (c UserFieldChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.From, c.To)
+}
+
+// Diff returns the fields holding different values in the other user,
+// which a nil user holds the zero values of
+func (u *userData) Diff(other User) []UserFieldChange {
+	if other == nil {
+		other = &userData{}
+	}
+
+	var changes []UserFieldChange
+	if !(u.id == other.ID()) {
+		changes = append(changes, UserFieldChange{Field: "ID", From: u.id, To: other.ID()})
+	}
+	if !(u.name == other.Name()) {
+		changes = append(changes, UserFieldChange{Field: "Name", From: u.name, To: other.Name()})
+	}
+	if !(equalUserIdentities(u.identities, other.Identities())) {
+		changes = append(changes, UserFieldChange{Field: "Identities", From: u.identities, To: other.Identities()})
+	}
+	if !(reflect.DeepEqual(u.profile, other.Profile())) {
+		changes = append(changes, UserFieldChange{Field: "Profile", From: u.profile, To: other.Profile()})
+	}
+	if !(equalUserWorkspaces(u.workspaces, other.Workspaces())) {
+		changes = append(changes, UserFieldChange{Field: "Workspaces", From: u.workspaces, To: other.Workspaces()})
+	}
+	return changes
+}
+
+func equalUserIdentities(a, b []user.Identity) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i0, v0 := range a {
+		if !(reflect.DeepEqual(v0, b[i0])) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalUserWorkspaces(a, b map[string]user.Workspace) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k0, v0 := range a {
+		w0, ok := b[k0]
+		if !ok {
+			return false
+		}
+		if !(reflect.DeepEqual(v0, w0)) {
+			return false
+		}
+	}
+	return true
+}
+
 // UserBuilder is a user builder
 type UserBuilder struct {
 	data userData
+	set  uint64
 }
 
 // NewUserBuilder returns a new user builder
@@ -43,6 +215,7 @@ func NewUserBuilder() *UserBuilder {
 // WithID sets the user id
 func (builder *UserBuilder) WithID(id string) *UserBuilder {
 	builder.data.id = id
+	builder.set |= 1 << 0
 	return builder
 }
 
@@ -73,8 +246,47 @@ func (builder *UserBuilder) WithWorkspaces(workspaces map[string]user.Workspace)
 // Data returns the user data
 func (builder *UserBuilder) Data() User { return &builder.data }
 
-// Build validates and returns the built user
+// UserMissingFieldsError lists the required user fields its
+// builder was never given
+type UserMissingFieldsError struct {
+	Fields []string
+}
+
+func (e *UserMissingFieldsError) Error() string {
+	return fmt.Sprintf("user is missing required fields: %s", strings.Join(e.Fields, ", "))
+}
+
+// missing returns the required user fields the builder was never given
+func (builder *UserBuilder) missing() []string {
+	var fields []string
+	if builder.set&(1<<0) == 0 {
+		fields = append(fields, "ID")
+	}
+	return fields
+}
+
+// Validate checks the user fields against their validation rules,
+// returning every failed rule
+func (builder *UserBuilder) Validate() error {
+	var errs []error
+	if builder.data.id == "" {
+		errs = append(errs, errors.New("user id is required"))
+	}
+	if len(builder.data.name) > 64 {
+		errs = append(errs, errors.New("user name must have a length of at most 64"))
+	}
+	return errors.Join(errs...)
+}
+
+// Build validates and returns the built user, failing with a
+// *UserMissingFieldsError when required fields were never set
 func (builder *UserBuilder) Build() (User, error) {
+	if missing := builder.missing(); len(missing) > 0 {
+		return nil, &UserMissingFieldsError{Fields: missing}
+	}
+	if err := builder.Validate(); err != nil {
+		return nil, err
+	}
 	if err := prebuild(builder); err != nil {
 		return nil, err
 	}
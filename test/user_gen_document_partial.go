@@ -6,6 +6,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// The UserDocumentPartial field names, for use in queries
+const (
+	UserDocumentPartialFieldID   = "_id"
+	UserDocumentPartialFieldName = "n"
+)
+
 type UserDocumentPartials []*UserDocumentPartial
 
 type UserDocumentPartial struct {
@@ -32,6 +38,11 @@ func (u UserDocumentPartial) MarshalBSON() ([]byte, error) {
 }
 
 func (u *UserDocumentPartial) UnmarshalBSON(data []byte) error {
+	// a null document, decoded without the registry of a parent
+	if len(data) == 0 {
+		return nil
+	}
+
 	var tmp userDocumentPartial
 	if err := bson.Unmarshal(data, &tmp); err != nil {
 		return err
@@ -59,3 +70,155 @@ func (docs UserDocumentPartials) Users() Users {
 	}
 	return users
 }
+
+// UserPartialFilter returns a builder of query filters on the UserDocumentPartial fields
+func UserPartialFilter() *UserDocumentPartialFilter {
+	return &UserDocumentPartialFilter{}
+}
+
+// UserDocumentPartialFilter builds a query filter matching all of its conditions
+type UserDocumentPartialFilter struct {
+	conditions bson.D
+}
+
+func (filter *UserDocumentPartialFilter) where(key, op string, value interface{}) *UserDocumentPartialFilter {
+	if op != "" {
+		value = bson.D{{Key: op, Value: value}}
+	}
+	filter.conditions = append(filter.conditions, bson.E{Key: key, Value: value})
+	return filter
+}
+
+func (filter *UserDocumentPartialFilter) IDEq(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "", v)
+}
+
+func (filter *UserDocumentPartialFilter) IDNe(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "$ne", v)
+}
+
+func (filter *UserDocumentPartialFilter) IDIn(vs ...string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "$in", vs)
+}
+
+func (filter *UserDocumentPartialFilter) IDNin(vs ...string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "$nin", vs)
+}
+
+func (filter *UserDocumentPartialFilter) IDGt(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "$gt", v)
+}
+
+func (filter *UserDocumentPartialFilter) IDGte(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "$gte", v)
+}
+
+func (filter *UserDocumentPartialFilter) IDLt(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "$lt", v)
+}
+
+func (filter *UserDocumentPartialFilter) IDLte(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldID, "$lte", v)
+}
+
+func (filter *UserDocumentPartialFilter) NameEq(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "", v)
+}
+
+func (filter *UserDocumentPartialFilter) NameNe(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "$ne", v)
+}
+
+func (filter *UserDocumentPartialFilter) NameIn(vs ...string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "$in", vs)
+}
+
+func (filter *UserDocumentPartialFilter) NameNin(vs ...string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "$nin", vs)
+}
+
+func (filter *UserDocumentPartialFilter) NameGt(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "$gt", v)
+}
+
+func (filter *UserDocumentPartialFilter) NameGte(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "$gte", v)
+}
+
+func (filter *UserDocumentPartialFilter) NameLt(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "$lt", v)
+}
+
+func (filter *UserDocumentPartialFilter) NameLte(v string) *UserDocumentPartialFilter {
+	return filter.where(UserDocumentPartialFieldName, "$lte", v)
+}
+
+// D returns the filter, combining the conditions with $and when several of
+// them apply to the same field
+func (filter *UserDocumentPartialFilter) D() bson.D {
+	seen := map[string]bool{}
+	for _, c := range filter.conditions {
+		if !seen[c.Key] {
+			seen[c.Key] = true
+			continue
+		}
+
+		and := make(bson.A, len(filter.conditions))
+		for i, c := range filter.conditions {
+			and[i] = bson.D{c}
+		}
+		return bson.D{{Key: "$and", Value: and}}
+	}
+
+	if filter.conditions == nil {
+		return bson.D{}
+	}
+	return filter.conditions
+}
+
+// UserPartialUpdate returns a builder of updates of the UserDocumentPartial fields
+func UserPartialUpdate() *UserDocumentPartialUpdate {
+	return &UserDocumentPartialUpdate{}
+}
+
+// UserDocumentPartialUpdate builds an update of $set, $unset and $inc operations
+type UserDocumentPartialUpdate struct {
+	set   bson.D
+	unset bson.D
+	inc   bson.D
+}
+
+func (update *UserDocumentPartialUpdate) SetID(v string) *UserDocumentPartialUpdate {
+	update.set = append(update.set, bson.E{Key: UserDocumentPartialFieldID, Value: v})
+	return update
+}
+
+func (update *UserDocumentPartialUpdate) UnsetID() *UserDocumentPartialUpdate {
+	update.unset = append(update.unset, bson.E{Key: UserDocumentPartialFieldID, Value: ""})
+	return update
+}
+
+func (update *UserDocumentPartialUpdate) SetName(v string) *UserDocumentPartialUpdate {
+	update.set = append(update.set, bson.E{Key: UserDocumentPartialFieldName, Value: v})
+	return update
+}
+
+func (update *UserDocumentPartialUpdate) UnsetName() *UserDocumentPartialUpdate {
+	update.unset = append(update.unset, bson.E{Key: UserDocumentPartialFieldName, Value: ""})
+	return update
+}
+
+// D returns the update document
+func (update *UserDocumentPartialUpdate) D() bson.D {
+	d := bson.D{}
+	for _, op := range []bson.E{
+		{Key: "$set", Value: update.set},
+		{Key: "$unset", Value: update.unset},
+		{Key: "$inc", Value: update.inc},
+	} {
+		if len(op.Value.(bson.D)) > 0 {
+			d = append(d, op)
+		}
+	}
+	return d
+}